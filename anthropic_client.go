@@ -2,19 +2,39 @@ package OpenLLM
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	anthropic "github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/shared/constant"
+	"github.com/golang-io/requests"
 )
 
 // 确保 Anthropic 实现了 LLM 接口
 // Ensure Anthropic implements the LLM interface
-var _ LLM = (*Anthropic)(nil)
+var (
+	_ LLM           = (*Anthropic)(nil)
+	_ EventStreamer = (*Anthropic)(nil)
+)
 
 // ============================================================================
 // Anthropic Claude SDK客户端封装 / Anthropic Claude SDK Client Wrapper
 // ============================================================================
 
+const (
+	// anthropicDefaultMaxTokens 模型不在目录中且未设置 MaxTokens 时发送的 max_tokens
+	// anthropicDefaultMaxTokens is the max_tokens sent for models missing from the catalog when MaxTokens is not set
+	anthropicDefaultMaxTokens = 8192
+
+	// anthropicNonStreamingMaxTokens SDK 允许非流式请求使用的最大 max_tokens（预计耗时不超过 10 分钟）
+	// anthropicNonStreamingMaxTokens is the largest max_tokens the SDK allows without streaming (at most 10 minutes expected)
+	anthropicNonStreamingMaxTokens = 128000 * 10 / 60
+)
+
 // Anthropic 封装 Anthropic Claude SDK 客户端
 // Anthropic wraps the Anthropic Claude SDK client
 type Anthropic struct {
@@ -26,10 +46,15 @@ type Anthropic struct {
 // CreateAnthropic creates a new Anthropic Claude client
 func CreateAnthropic(opts ...Option) *Anthropic {
 	options := newOptions(opts)
+	requestOptions := []option.RequestOption{
+		option.WithAPIKey(options.APIKey), // defaults to os.LookupEnv("ANTHROPIC_API_KEY")
+		option.WithHTTPClient(requests.New().HTTPClient(options.httpClientOptions()...)),
+	}
+	if options.URL != "" {
+		requestOptions = append(requestOptions, option.WithBaseURL(options.URL))
+	}
 	return &Anthropic{
-		client: anthropic.NewClient(
-			option.WithAPIKey(options.APIKey), // defaults to os.LookupEnv("ANTHROPIC_API_KEY")
-		),
+		client:  anthropic.NewClient(requestOptions...),
 		options: opts,
 	}
 }
@@ -41,66 +66,405 @@ func CreateAnthropic(opts ...Option) *Anthropic {
 // Completion 执行单次对话完成（非流式）
 // Completion performs a single conversation completion (non-streaming)
 func (a *Anthropic) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
-	options := newOptions(opts, a.options...)
-
-	message, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
-		MaxTokens: int64(options.MaxTokens),
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(input.Messages[0].Content)),
-		},
-		Model: anthropic.Model(input.Model),
-	})
+	// 1. 适配：Union类型 → SDK原生类型
+	params, err := a.messageParams(input, false, opts...)
 	if err != nil {
-		return nil, err
+		return nil, NewLLMError(ProviderClaude, "CONVERT_ERROR", "转换请求参数失败", err)
 	}
-	return &Output{
-		Content: message.Content[0].Text,
-	}, nil
+
+	// 2. 记录开始时间
+	startTime := time.Now()
+
+	// 3. 调用底层SDK（使用原生类型）
+	message, err := a.client.Messages.New(ctx, params)
+	if err != nil {
+		return nil, newAPIError(ProviderClaude, "Anthropic API调用失败", err)
+	}
+
+	// 4. 适配：SDK原生类型 → Union类型
+	output, err := fromAnthropicResponse(message, startTime, input.ResponseFormat)
+	if err != nil {
+		return nil, NewLLMError(ProviderClaude, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderClaude, input, output)
 }
 
 // CompletionStream 执行单次对话完成（流式）
 // CompletionStream performs a single conversation completion (streaming)
 func (a *Anthropic) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
-	options := newOptions(opts, a.options...)
-
-	stream := a.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		MaxTokens: int64(options.MaxTokens),
-		Messages: []anthropic.MessageParam{
-			anthropic.NewUserMessage(anthropic.NewTextBlock(input.Messages[0].Content)),
-		},
-		Model: anthropic.Model(input.Model),
-	})
+	return a.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// 结构化输出工具的参数增量作为回复文本输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+// Argument deltas of the structured output tool are emitted as answer text
+func (a *Anthropic) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	params, err := a.messageParams(input, true, opts...)
+	if err != nil {
+		return nil, NewLLMError(ProviderClaude, "CONVERT_ERROR", "转换请求参数失败", err)
+	}
+
+	startTime := time.Now()
+	stream := a.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	// 流中断时返回已收到的部分输出 / Return the partial output when the stream is interrupted
+	recorder := newStreamRecorder()
+	handler = recorder.wrap(handler)
 
 	message := anthropic.Message{}
+	// formatBlocks 记录结构化输出工具所在的内容块 / formatBlocks marks content blocks of the structured output tool
+	formatBlocks := make(map[int64]bool)
 	for stream.Next() {
 		event := stream.Current()
-		err := message.Accumulate(event)
-		if err != nil {
-			panic(err)
+		if err := message.Accumulate(event); err != nil {
+			return recorder.interrupt(ctx, handler, NewLLMError(ProviderClaude, "STREAM_ERROR", "累积Anthropic流式响应失败", err))
 		}
 
 		switch eventVariant := event.AsAny().(type) {
+		case anthropic.ContentBlockStartEvent:
+			block := eventVariant.ContentBlock
+			if block.Type != "tool_use" {
+				continue
+			}
+			if input.ResponseFormat != nil && block.Name == input.ResponseFormat.name() {
+				formatBlocks[eventVariant.Index] = true
+				continue
+			}
+			handler.toolCall(ToolCallDelta{Index: int(eventVariant.Index), ID: block.ID, Name: block.Name})
+
 		case anthropic.ContentBlockDeltaEvent:
 			switch deltaVariant := eventVariant.Delta.AsAny().(type) {
 			case anthropic.TextDelta:
-				streamOutput(deltaVariant.Text)
+				handler.content(deltaVariant.Text)
+			case anthropic.ThinkingDelta:
+				handler.thinking(deltaVariant.Thinking)
+			case anthropic.InputJSONDelta:
+				if formatBlocks[eventVariant.Index] {
+					handler.content(deltaVariant.PartialJSON)
+					continue
+				}
+				handler.toolCall(ToolCallDelta{Index: int(eventVariant.Index), Arguments: deltaVariant.PartialJSON})
 			}
-
 		}
 	}
 
-	if stream.Err() != nil {
-		return nil, stream.Err()
+	if err := stream.Err(); err != nil {
+		return recorder.interrupt(ctx, handler, newAPIError(ProviderClaude, "Anthropic API调用失败", err))
 	}
-	return &Output{
-		Content: message.Content[0].Text,
-	}, nil
+	// 工具调用参数无效时仍返回已收到的内容和 token 用量
+	output, err := fromAnthropicResponse(&message, startTime, input.ResponseFormat)
+	recorder.finish(handler, output)
+	if err != nil {
+		return output, NewLLMError(ProviderClaude, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderClaude, input, output)
 }
 
 // Provider 获取提供商信息
 // Provider returns the provider information
 func (a *Anthropic) Provider() ProviderInfo {
-	return ProviderInfo{
-		Type: "anthropic",
+	return providerInfo(a.options, ProviderInfo{
+		Type:       ProviderClaude,
+		Name:       "Anthropic Claude",
+		parameters: a.requestParameters,
+	})
+}
+
+// requestParameters 返回请求实际发送的生成参数
+// requestParameters returns the generation parameters actually sent with the request
+func (a *Anthropic) requestParameters(input *Input, stream bool, opts []Option) requestParameters {
+	params, err := a.messageParams(&Input{Model: input.Model}, stream, opts...)
+	if err != nil {
+		return requestParameters{}
+	}
+	return requestParameters{
+		Temperature: sdkParameter[float64](params.Temperature),
+		TopP:        sdkParameter[float64](params.TopP),
+		MaxTokens:   &params.MaxTokens,
+	}
+}
+
+// ============================================================================
+// 适配逻辑 / Adapter Logic
+// 将Union类型转换为SDK原生类型，或将SDK原生类型转换为Union类型
+// ============================================================================
+
+// anthropicMaxTokens 返回发送给 Anthropic 的 max_tokens（该参数必填）：显式设置时按模型目录中的上限截断，
+// 未设置时使用目录中的上限，模型不在目录中时使用 anthropicDefaultMaxTokens
+// anthropicMaxTokens returns the max_tokens sent to Anthropic, which is required: an explicit value is capped at the
+// limit from the model catalog, otherwise the catalog limit is used, or anthropicDefaultMaxTokens for unknown models
+func anthropicMaxTokens(options *Options, model string) int64 {
+	if maxTokens, ok := options.maxTokens(); ok {
+		return capMaxTokens(maxTokens, model)
+	}
+	if info, ok := LookupModel(model); ok && info.MaxOutputTokens > 0 {
+		return info.MaxOutputTokens
+	}
+	return anthropicDefaultMaxTokens
+}
+
+// anthropicNonStreamingLimit 将 max_tokens 限制在 SDK 允许的非流式请求范围内
+// anthropicNonStreamingLimit limits max_tokens to the range the SDK allows for non-streaming requests
+func anthropicNonStreamingLimit(model anthropic.Model, maxTokens int64) int64 {
+	limit := int64(anthropicNonStreamingMaxTokens)
+	if n, ok := constant.ModelNonStreamingTokens[string(model)]; ok {
+		limit = min(limit, int64(n))
+	}
+	return min(maxTokens, limit)
+}
+
+// messageParams 生成请求参数；未显式设置 MaxTokens 时，非流式请求限制在 SDK 允许的范围内，避免被 SDK 拒绝
+// messageParams builds the request parameters; without an explicit MaxTokens, non-streaming requests are limited
+// to the range the SDK allows so that the SDK does not reject them
+func (a *Anthropic) messageParams(input *Input, stream bool, opts ...Option) (anthropic.MessageNewParams, error) {
+	params, err := a.GenerateAnthropicMessageNewParams(input, opts...)
+	if err != nil {
+		return anthropic.MessageNewParams{}, err
+	}
+	if _, ok := newOptions(a.options, opts...).maxTokens(); !ok && !stream {
+		params.MaxTokens = anthropicNonStreamingLimit(params.Model, params.MaxTokens)
+	}
+	return params, nil
+}
+
+// GenerateAnthropicMessageNewParams 将Union请求转换为Anthropic SDK原生参数
+// GenerateAnthropicMessageNewParams converts Union request to Anthropic SDK native parameters
+func (a *Anthropic) GenerateAnthropicMessageNewParams(input *Input, opts ...Option) (anthropic.MessageNewParams, error) {
+	options := newOptions(a.options, opts...)
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(options.modelName(input)),
+		MaxTokens: anthropicMaxTokens(options, options.modelName(input)),
+	}
+	// Anthropic 部分模型不允许同时设置 temperature 和 top_p：显式配置 top_p 时只发送 top_p，否则只发送 temperature
+	if options.TopP > 0 {
+		params.TopP = anthropic.Float(options.TopP)
+	} else {
+		params.Temperature = anthropic.Float(options.Temperature)
+	}
+
+	// 1. 转换消息：系统消息单独放入 System，其余消息按角色合并为交替的轮次
+	for _, msg := range input.Messages {
+		if msg.Role == RoleSystem {
+			params.System = append(params.System, anthropic.TextBlockParam{Text: msg.Text()})
+			continue
+		}
+
+		role, blocks, err := toAnthropicContentBlocks(msg)
+		if err != nil {
+			return anthropic.MessageNewParams{}, err
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+
+		// Anthropic 要求 user/assistant 严格交替，连续的同角色消息（如多个 tool_result）合并到同一轮
+		if n := len(params.Messages); n > 0 && params.Messages[n-1].Role == role {
+			params.Messages[n-1].Content = append(params.Messages[n-1].Content, blocks...)
+			continue
+		}
+		params.Messages = append(params.Messages, anthropic.MessageParam{Role: role, Content: blocks})
+	}
+
+	// 2. 转换工具定义
+	for _, tool := range input.Tools {
+		anthropicTool, err := tool.ToAnthropicTool()
+		if err != nil {
+			return anthropic.MessageNewParams{}, fmt.Errorf("转换工具 %s 失败: %w", tool.Name, err)
+		}
+		params.Tools = append(params.Tools, anthropicTool)
+	}
+
+	// 3. 转换工具选择策略
+	if input.ToolChoice != nil {
+		params.ToolChoice = toAnthropicToolChoice(*input.ToolChoice)
+	}
+
+	// 4. 结构化输出：Anthropic 没有原生的 response_format，通过强制调用以 Schema 为参数的工具实现
+	if format := input.ResponseFormat; format != nil {
+		if err := format.Validate(); err != nil {
+			return anthropic.MessageNewParams{}, fmt.Errorf("结构化输出格式验证失败: %w", err)
+		}
+		description := format.Description
+		if description == "" {
+			description = "按指定的JSON格式返回结果 / Respond with the specified JSON format"
+		}
+		tool := Tool{Name: format.name(), Description: description, Parameters: format.Schema}
+		anthropicTool, err := tool.ToAnthropicTool()
+		if err != nil {
+			return anthropic.MessageNewParams{}, fmt.Errorf("转换结构化输出格式失败: %w", err)
+		}
+		params.Tools = append(params.Tools, anthropicTool)
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(tool.Name)
+	}
+
+	return params, nil
+}
+
+// toAnthropicContentBlocks 将Message消息转换为Anthropic内容块
+// toAnthropicContentBlocks converts Message message to Anthropic content blocks
+func toAnthropicContentBlocks(msg Message) (anthropic.MessageParamRole, []anthropic.ContentBlockParamUnion, error) {
+	switch msg.Role {
+	case RoleUser:
+		if len(msg.Parts) == 0 {
+			return anthropic.MessageParamRoleUser, []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(msg.Content)}, nil
+		}
+		blocks, err := toAnthropicContentParts(msg.Parts)
+		return anthropic.MessageParamRoleUser, blocks, err
+
+	case RoleAssistant:
+		var blocks []anthropic.ContentBlockParamUnion
+		if text := msg.Text(); text != "" {
+			blocks = append(blocks, anthropic.NewTextBlock(text))
+		}
+		for _, tc := range msg.ToolCalls {
+			args := tc.Arguments
+			if args == nil {
+				args = map[string]any{}
+			}
+			blocks = append(blocks, anthropic.NewToolUseBlock(tc.ID, args, tc.Name))
+		}
+		return anthropic.MessageParamRoleAssistant, blocks, nil
+
+	case RoleTool:
+		// 工具结果在 Anthropic 中以 user 轮次的 tool_result 块表示
+		return anthropic.MessageParamRoleUser, []anthropic.ContentBlockParamUnion{anthropic.NewToolResultBlock(msg.ToolCallID, msg.Text(), msg.IsError)}, nil
+
+	default:
+		return "", nil, fmt.Errorf("不支持的消息角色: %s", msg.Role)
+	}
+}
+
+// toAnthropicContentParts 将多模态内容片段转换为Anthropic内容块
+// toAnthropicContentParts converts multimodal content parts to Anthropic content blocks
+func toAnthropicContentParts(parts []ContentPart) ([]anthropic.ContentBlockParamUnion, error) {
+	blocks := make([]anthropic.ContentBlockParamUnion, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case PartText:
+			blocks = append(blocks, anthropic.NewTextBlock(part.Text))
+
+		case PartImageURL:
+			blocks = append(blocks, anthropic.NewImageBlock(anthropic.URLImageSourceParam{URL: part.URL}))
+
+		case PartImage:
+			blocks = append(blocks, anthropic.NewImageBlockBase64(part.MIMEType, base64.StdEncoding.EncodeToString(part.Data)))
+
+		case PartFile:
+			// Anthropic 文档块支持 PDF（内联或URL）和纯文本
+			switch {
+			case part.MIMEType == "text/plain" && len(part.Data) > 0:
+				blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.PlainTextSourceParam{Data: string(part.Data)}))
+			case part.MIMEType != "application/pdf":
+				return nil, fmt.Errorf("Anthropic 不支持的文件类型: %s", part.MIMEType)
+			case len(part.Data) > 0:
+				blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: base64.StdEncoding.EncodeToString(part.Data)}))
+			default:
+				blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.URLPDFSourceParam{URL: part.URL}))
+			}
+
+		default:
+			return nil, fmt.Errorf("Anthropic 不支持的内容片段类型: %s", part.Type)
+		}
+	}
+	return blocks, nil
+}
+
+// toAnthropicToolChoice 将Tool工具选择转换为Anthropic格式
+// toAnthropicToolChoice converts Union tool choice to Anthropic format
+func toAnthropicToolChoice(choice ToolChoiceOption) anthropic.ToolChoiceUnionParam {
+	switch choice.Type {
+	case ToolChoiceRequired:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	case ToolChoiceNone:
+		none := anthropic.NewToolChoiceNoneParam()
+		return anthropic.ToolChoiceUnionParam{OfNone: &none}
+	case ToolChoiceSpecific:
+		return anthropic.ToolChoiceParamOfTool(choice.ToolName)
+	default:
+		return anthropic.ToolChoiceUnionParam{OfAuto: &anthropic.ToolChoiceAutoParam{}}
+	}
+}
+
+// fromAnthropicResponse 将Anthropic SDK响应转换为Union响应，结构化输出工具的调用参数作为输出内容
+// 工具调用参数不是有效的 JSON 对象时同时返回输出和错误，该工具调用的参数为空
+// fromAnthropicResponse converts Anthropic SDK response to Union response, using the arguments of the structured
+// output tool call as the output content
+// When tool call arguments are not a valid JSON object, the output is returned together with an error
+// and the arguments of that tool call are left empty
+func fromAnthropicResponse(message *anthropic.Message, startTime time.Time, format *ResponseFormat) (*Output, error) {
+	output := &Output{
+		StartAt:      startTime,
+		FinishReason: fromAnthropicStopReason(message.StopReason),
+		Cost:         time.Since(startTime),
+		RawResponse:  message,
+	}
+
+	var (
+		content, thinking strings.Builder
+		structured        []byte
+		errs              []error
+	)
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "thinking":
+			thinking.WriteString(block.Thinking)
+		case "tool_use":
+			// 结构化输出工具的参数原样作为内容，由结构化输出校验报告无效的 JSON
+			if format != nil && block.Name == format.name() {
+				structured = block.Input
+				continue
+			}
+			args, err := parseToolArguments(string(block.Input))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("工具 %s（%s）: %w", block.Name, block.ID, err))
+			}
+			output.ToolCalls = append(output.ToolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: args,
+			})
+		}
+	}
+	output.Content = content.String()
+	output.Thinking = thinking.String()
+	if structured != nil {
+		output.Content = string(structured)
+		if len(output.ToolCalls) == 0 {
+			output.FinishReason = string(FinishReasonStop)
+		}
+	}
+
+	// Anthropic 的 input_tokens 不含缓存部分，这里合并为完整的输入 token 数
+	usage := message.Usage
+	inputTokens := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	output.TokenUsage = TokenUsage{
+		InputTokens:  inputTokens,
+		CachedTokens: usage.CacheReadInputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  inputTokens + usage.OutputTokens,
+	}
+
+	return output, errors.Join(errs...)
+}
+
+// fromAnthropicStopReason 将Anthropic停止原因转换为Union结束原因
+// fromAnthropicStopReason converts Anthropic stop reason to Union finish reason
+func fromAnthropicStopReason(reason anthropic.StopReason) string {
+	switch reason {
+	case anthropic.StopReasonEndTurn, anthropic.StopReasonStopSequence, anthropic.StopReasonPauseTurn:
+		return string(FinishReasonStop)
+	case anthropic.StopReasonMaxTokens:
+		return string(FinishReasonLength)
+	case anthropic.StopReasonToolUse:
+		return string(FinishReasonToolCalls)
+	case anthropic.StopReasonRefusal:
+		return string(FinishReasonContentFilter)
+	default:
+		return string(reason)
 	}
 }
@@ -0,0 +1,231 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	anthropic "github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestAnthropic_GenerateAnthropicMessageNewParams(t *testing.T) {
+	client := CreateAnthropic(APIKey("test"))
+	params, err := client.GenerateAnthropicMessageNewParams(&Input{
+		Model: "claude-sonnet-4-5",
+		Messages: []Message{
+			SystemMessage("你是一个天气助手"),
+			UserMessage("查询北京的天气"),
+			AssistantMessageWithTools("", []ToolCall{
+				{ID: "toolu_1", Name: "query_weather", Arguments: map[string]any{"city": "beijing"}},
+				{ID: "toolu_2", Name: "current_time", Arguments: map[string]any{"tz": "Asia/Shanghai"}},
+			}),
+			ToolMessage("晴", "toolu_1"),
+			ToolMessage("2025-01-01 10:00:00", "toolu_2"),
+		},
+		Tools:      Tools,
+		ToolChoice: &ToolChoiceOption{Type: ToolChoiceRequired},
+	}, MaxTokens(1024), Temperature(0.5))
+	if err != nil {
+		t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+	}
+
+	if len(params.System) != 1 || params.System[0].Text != "你是一个天气助手" {
+		t.Errorf("Expected one system block, got %+v", params.System)
+	}
+	if params.MaxTokens != 1024 {
+		t.Errorf("Expected max tokens 1024, got %d", params.MaxTokens)
+	}
+	if params.Temperature.Or(0) != 0.5 {
+		t.Errorf("Expected temperature 0.5, got %v", params.Temperature.Or(0))
+	}
+	if params.TopP.Valid() {
+		t.Errorf("Expected top_p to be omitted, got %v", params.TopP.Or(0))
+	}
+
+	// user → assistant(tool_use×2) → user(tool_result×2)
+	if len(params.Messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(params.Messages))
+	}
+	if got := len(params.Messages[1].Content); got != 2 {
+		t.Errorf("Expected 2 tool_use blocks, got %d", got)
+	}
+	results := params.Messages[2]
+	if results.Role != anthropic.MessageParamRoleUser || len(results.Content) != 2 {
+		t.Fatalf("Expected tool results merged into one user turn, got %+v", results)
+	}
+	if results.Content[1].OfToolResult == nil || results.Content[1].OfToolResult.ToolUseID != "toolu_2" {
+		t.Errorf("Expected second tool_result for toolu_2, got %+v", results.Content[1])
+	}
+
+	if len(params.Tools) != len(Tools) {
+		t.Errorf("Expected %d tools, got %d", len(Tools), len(params.Tools))
+	}
+	if params.ToolChoice.OfAny == nil {
+		t.Errorf("Expected tool choice any, got %+v", params.ToolChoice)
+	}
+}
+
+func TestAnthropic_fromAnthropicResponse(t *testing.T) {
+	raw := `{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-sonnet-4-5",
+		"content": [
+			{"type": "thinking", "thinking": "需要查询天气", "signature": "sig"},
+			{"type": "text", "text": "我来查询一下。"},
+			{"type": "tool_use", "id": "toolu_1", "name": "query_weather", "input": {"city": "beijing"}}
+		],
+		"stop_reason": "tool_use",
+		"stop_sequence": null,
+		"usage": {"input_tokens": 10, "cache_read_input_tokens": 5, "output_tokens": 20}
+	}`
+	var message anthropic.Message
+	if err := json.Unmarshal([]byte(raw), &message); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	output, err := fromAnthropicResponse(&message, time.Now(), nil)
+	if err != nil {
+		t.Fatalf("fromAnthropicResponse() error = %v", err)
+	}
+	if output.Content != "我来查询一下。" {
+		t.Errorf("Expected content, got %q", output.Content)
+	}
+	if output.Thinking != "需要查询天气" {
+		t.Errorf("Expected thinking, got %q", output.Thinking)
+	}
+	if output.FinishReason != string(FinishReasonToolCalls) {
+		t.Errorf("Expected finish reason tool_calls, got %s", output.FinishReason)
+	}
+	if len(output.ToolCalls) != 1 || output.ToolCalls[0].Arguments["city"] != "beijing" {
+		t.Errorf("Expected one query_weather tool call, got %+v", output.ToolCalls)
+	}
+	if output.TokenUsage.InputTokens != 15 || output.TokenUsage.TotalTokens != 35 {
+		t.Errorf("Unexpected token usage: %+v", output.TokenUsage)
+	}
+}
+
+func TestAnthropic_InvalidToolArguments(t *testing.T) {
+	const raw = `{"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-sonnet-4-5",
+		"content": [{"type": "tool_use", "id": "toolu_1", "name": "query_weather", "input": ["beijing"]}],
+		"stop_reason": "tool_use", "usage": {"input_tokens": 10, "output_tokens": 20}}`
+	server := newErrorServer(t, http.StatusOK, nil, raw)
+	_, err := CreateAnthropic(URL(server.URL), APIKey("test")).Completion(context.Background(), &Input{
+		Model:    "claude-sonnet-4-5",
+		Messages: []Message{UserMessage("北京天气")},
+	}, MaxTokens(1024))
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "INVALID_TOOL_ARGUMENTS" {
+		t.Fatalf("Expected INVALID_TOOL_ARGUMENTS error, got %v", err)
+	}
+}
+
+func TestAnthropic_MaxTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		opts  []Option
+		want  int64
+	}{
+		{"目录上限", "claude-sonnet-4-5", nil, 64000},
+		{"带日期的版本", "claude-opus-4-1-20250805", nil, 32000},
+		{"未知模型", "claude-internal", nil, anthropicDefaultMaxTokens},
+		{"显式设置", "claude-sonnet-4-5", []Option{MaxTokens(2048)}, 2048},
+		{"按模型上限截断", "claude-sonnet-4-5", []Option{MaxTokens(defaultMaxTokens)}, 64000},
+		{"未知模型显式设置", "claude-internal", []Option{MaxTokens(100000)}, 100000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := CreateAnthropic(APIKey("test")).GenerateAnthropicMessageNewParams(&Input{Model: tt.model}, tt.opts...)
+			if err != nil {
+				t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+			}
+			if params.MaxTokens != tt.want {
+				t.Errorf("Expected max tokens %d, got %d", tt.want, params.MaxTokens)
+			}
+		})
+	}
+
+	// 未设置 MaxTokens 的非流式调用不会被 SDK 拒绝
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id": "msg_1", "type": "message", "role": "assistant", "model": "claude-opus-4-1-20250805",
+			"content": [{"type": "text", "text": "你好"}], "stop_reason": "end_turn", "usage": {"input_tokens": 1, "output_tokens": 1}}`)
+	}))
+	defer server.Close()
+	for model, want := range map[string]float64{"claude-sonnet-4-5": anthropicNonStreamingMaxTokens, "claude-opus-4-1-20250805": 8192} {
+		output, err := CreateAnthropic(URL(server.URL), APIKey("test")).Completion(context.Background(), &Input{Model: model, Messages: []Message{UserMessage("你好")}})
+		if err != nil || output.Content != "你好" || body["max_tokens"] != want {
+			t.Errorf("Expected %s to be sent with max_tokens %v, got %v, %v", model, want, body["max_tokens"], err)
+		}
+	}
+}
+
+func TestAnthropic_SamplingAndToolErrors(t *testing.T) {
+	input := &Input{
+		Model: "claude-sonnet-4-5",
+		Messages: []Message{
+			UserMessage("北京天气"),
+			AssistantMessageWithTools("", []ToolCall{{ID: "toolu_1", Name: "query_weather", Arguments: map[string]any{"city": "北京"}}}),
+			ToolErrorMessage("工具 query_weather 执行失败: 超时", "toolu_1"),
+		},
+	}
+
+	params, err := CreateAnthropic(APIKey("test")).GenerateAnthropicMessageNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+	}
+	if !params.Temperature.Valid() || params.TopP.Valid() {
+		t.Errorf("Expected only temperature by default, got temperature=%v top_p=%v", params.Temperature, params.TopP)
+	}
+	if result := params.Messages[2].Content[0].OfToolResult; result == nil || !result.IsError.Or(false) {
+		t.Errorf("Expected tool_result with is_error, got %+v", params.Messages[2].Content[0])
+	}
+
+	params, err = CreateAnthropic(APIKey("test"), TopP(0.9)).GenerateAnthropicMessageNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+	}
+	if params.Temperature.Valid() || params.TopP.Or(0) != 0.9 {
+		t.Errorf("Expected only top_p when it is set, got temperature=%v top_p=%v", params.Temperature, params.TopP)
+	}
+}
+
+func TestAnthropic_MultimodalMessage(t *testing.T) {
+	client := CreateAnthropic(APIKey("test"))
+	params, err := client.GenerateAnthropicMessageNewParams(&Input{
+		Model: "claude-sonnet-4-5",
+		Messages: []Message{UserMessageWithParts(
+			TextPart("总结这份文档"),
+			ImagePart([]byte("png"), "image/png"),
+			FileURLPart("https://example.com/a.pdf", "application/pdf"),
+		)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+	}
+	blocks := params.Messages[0].Content
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 content blocks, got %d", len(blocks))
+	}
+	if blocks[1].OfImage == nil || blocks[1].OfImage.Source.OfBase64.Data != "cG5n" {
+		t.Errorf("Expected base64 image block, got %+v", blocks[1])
+	}
+	if blocks[2].OfDocument == nil || blocks[2].OfDocument.Source.OfURL == nil {
+		t.Errorf("Expected url document block, got %+v", blocks[2])
+	}
+
+	if _, err := client.GenerateAnthropicMessageNewParams(&Input{
+		Messages: []Message{UserMessageWithParts(AudioPart([]byte("wav"), "audio/wav"))},
+	}); err == nil {
+		t.Error("Expected error for audio content")
+	}
+}
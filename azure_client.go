@@ -2,14 +2,17 @@ package OpenLLM
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/packages/param"
 )
 
-var _ LLM = (*Azure)(nil)
+var (
+	_ LLM           = (*Azure)(nil)
+	_ EventStreamer = (*Azure)(nil)
+)
 
 // ============================================================================
 // Azure OpenAI SDK客户端封装 / Azure OpenAI SDK Client Wrapper
@@ -66,7 +69,7 @@ func (a *Azure) Completion(ctx context.Context, input *Input, opts ...Option) (*
 	// 4. 调用底层SDK（使用原生类型）
 	completion, err := a.client.ChatCompletion(ctx, params)
 	if err != nil {
-		return nil, NewLLMError(ProviderAzure, "API_ERROR", "Azure OpenAI API调用失败", err)
+		return nil, newAPIError(ProviderAzure, "Azure OpenAI API调用失败", err)
 	}
 
 	// 5. 计算耗时
@@ -78,24 +81,37 @@ func (a *Azure) Completion(ctx context.Context, input *Input, opts ...Option) (*
 	}
 
 	// 7. 适配：SDK原生类型 → Union类型
-	return fromAzureResponse(completion, duration), nil
+	output, err := fromAzureResponse(completion, duration)
+	if err != nil {
+		return nil, NewLLMError(ProviderAzure, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderAzure, input, output)
 }
 
 // CompletionStream 执行单次对话完成（流式）
 // CompletionStream performs a single conversation completion (streaming)
 func (a *Azure) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return a.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+func (a *Azure) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
 	params, err := a.GenerateAzureChatCompletionNewParams(input, opts...)
 	if err != nil {
 		return nil, NewLLMError(ProviderAzure, "CONVERT_ERROR", "转换请求参数失败", err)
 	}
+	setStreamUsage(&params, newOptions(a.options, opts...))
 
 	// 3. 记录开始时间
 	startTime := time.Now()
 
-	// 4. 调用底层SDK（使用原生类型）
-	completion, err := a.client.ChatCompletionStream(ctx, params, streamOutput)
+	// 4. 调用底层SDK（使用原生类型），流中断时返回已收到的部分输出
+	recorder := newStreamRecorder()
+	handler = recorder.wrap(handler)
+	completion, err := a.client.ChatCompletionStreamEvents(ctx, params, handler)
 	if err != nil {
-		return nil, NewLLMError(ProviderAzure, "API_ERROR", "Azure OpenAI API调用失败", err)
+		return recorder.interrupt(ctx, handler, newAPIError(ProviderAzure, "Azure OpenAI API调用失败", err))
 	}
 
 	// 检查是否有响应内容 / Check if response has content
@@ -103,27 +119,42 @@ func (a *Azure) CompletionStream(ctx context.Context, input *Input, streamOutput
 		return nil, NewLLMError(ProviderAzure, "EMPTY_RESPONSE", "Azure OpenAI API返回空响应", nil)
 	}
 
-	return fromAzureResponse(completion, time.Since(startTime)), nil
+	output, err := fromAzureResponse(completion, time.Since(startTime))
+	recorder.finish(handler, output)
+	if err != nil {
+		return output, NewLLMError(ProviderAzure, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderAzure, input, output)
 }
 
 // Provider 获取提供商信息
 // Provider returns the provider information
 func (p *Azure) Provider() ProviderInfo {
-	return ProviderInfo{
+	info := providerInfo(p.options, ProviderInfo{
 		Type:    ProviderAzure,
 		Name:    "Azure OpenAI",
 		Version: "v1",
-		// Model:   p.config.Model,
-		// BaseURL: p.config.BaseURL,
 		Capabilities: ProviderCapabilities{
 			ToolCall:      true,
 			Streaming:     true,
-			Temperature:   true,
-			TopP:          true,
-			Seed:          true,
 			SystemMessage: true,
 		},
+		parameters: p.requestParameters,
+	})
+	// 采样参数在请求时总是被忽略，模型目录中的标识不适用于 Azure
+	// Sampling parameters are always dropped from requests, so the catalog flags do not apply to Azure
+	info.Capabilities.Temperature, info.Capabilities.TopP, info.Capabilities.Seed = false, false, false
+	return info
+}
+
+// requestParameters 返回请求实际发送的生成参数（不含被忽略的采样参数）
+// requestParameters returns the generation parameters actually sent with the request (without the dropped sampling parameters)
+func (p *Azure) requestParameters(input *Input, stream bool, opts []Option) requestParameters {
+	params, err := p.GenerateAzureChatCompletionNewParams(&Input{Model: input.Model}, opts...)
+	if err != nil {
+		return requestParameters{}
 	}
+	return openAIRequestParameters(params)
 }
 
 // ============================================================================
@@ -142,20 +173,26 @@ func (a *Azure) GenerateAzureChatCompletionNewParams(input *Input, opts ...Optio
 	if err != nil {
 		return openai.ChatCompletionNewParams{}, err
 	}
-	// 复制配置以避免修改原始配置
-
+	// 忽略Azure不支持的参数 / Drop the parameters Azure does not support
+	var ignored []string
 	if params.Temperature.Valid() {
-		log.Printf("[Azure] 警告: Azure OpenAI不支持Temperature参数，将被忽略")
+		ignored = append(ignored, "temperature")
 		params.Temperature = param.Opt[float64]{}
 	}
 	if params.TopP.Valid() {
-		log.Printf("[Azure] 警告: Azure OpenAI不支持TopP参数，将被忽略")
+		ignored = append(ignored, "top_p")
 		params.TopP = param.Opt[float64]{}
 	}
 	if params.Seed.Valid() {
-		log.Printf("[Azure] 警告: Azure OpenAI不支持Seed参数，将被忽略")
+		ignored = append(ignored, "seed")
 		params.Seed = param.Opt[int64]{}
 	}
+	if len(ignored) > 0 {
+		// 默认配置总会设置这些参数，因此以 Debug 级别记录，避免每次调用都输出警告
+		// The defaults always set these parameters, so log at Debug level instead of warning on every call
+		newOptions(a.options, opts...).logger().Debug("Azure OpenAI不支持以下参数，将被忽略",
+			slog.String("provider", string(ProviderAzure)), slog.Any("params", ignored))
+	}
 
 	return params, nil
 }
@@ -164,6 +201,6 @@ func (a *Azure) GenerateAzureChatCompletionNewParams(input *Input, opts ...Optio
 // Azure兼容OpenAI协议，直接复用OpenAI的转换逻辑
 // fromAzureResponse converts Azure OpenAI response to Union response
 // Azure is compatible with OpenAI protocol, reuses OpenAI's conversion logic
-func fromAzureResponse(completion *openai.ChatCompletion, duration time.Duration) *Output {
+func fromAzureResponse(completion *openai.ChatCompletion, duration time.Duration) (*Output, error) {
 	return fromOpenAIResponse(completion, duration)
 }
@@ -0,0 +1,210 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/openai/openai-go/v3"
+	"google.golang.org/genai"
+)
+
+// ============================================================================
+// 错误分类 / Error Classification
+// ============================================================================
+
+// 提供商 API 错误的错误码，与提供商无关
+// Error codes of provider API errors, independent of the provider
+const (
+	ErrCodeAPI                   = "API_ERROR"               // 无法进一步分类的 API 错误 / API error that could not be classified further
+	ErrCodeRateLimited           = "RATE_LIMITED"            // 请求频率超限 / Rate limit exceeded
+	ErrCodeQuotaExceeded         = "QUOTA_EXCEEDED"          // 额度用尽或账单问题 / Quota exhausted or billing problem
+	ErrCodeAuth                  = "AUTH_ERROR"              // 认证或权限错误 / Authentication or permission error
+	ErrCodeInvalidRequest        = "INVALID_REQUEST"         // 请求参数错误 / Invalid request
+	ErrCodeContextLengthExceeded = "CONTEXT_LENGTH_EXCEEDED" // 超出模型上下文长度 / Model context length exceeded
+	ErrCodeContentFiltered       = "CONTENT_FILTERED"        // 被内容安全策略拦截 / Blocked by the content policy
+	ErrCodeOverloaded            = "OVERLOADED"              // 服务过载或暂时不可用 / Service overloaded or temporarily unavailable
+	ErrCodeTimeout               = "TIMEOUT"                 // 请求超时 / Request timed out
+	ErrCodeServerError           = "SERVER_ERROR"            // 服务端内部错误 / Internal server error
+	ErrCodeCancelled             = "CANCELLED"               // 调用方取消了请求 / Request cancelled by the caller
+)
+
+// 用于 errors.Is 的哨兵错误，按错误码匹配任意提供商的 *LLMError
+// Sentinel errors for errors.Is, matching an *LLMError of any provider by its code
+var (
+	ErrRateLimited           = &LLMError{Code: ErrCodeRateLimited, Message: "请求频率超限"}
+	ErrQuotaExceeded         = &LLMError{Code: ErrCodeQuotaExceeded, Message: "额度不足"}
+	ErrAuth                  = &LLMError{Code: ErrCodeAuth, Message: "认证失败"}
+	ErrInvalidRequest        = &LLMError{Code: ErrCodeInvalidRequest, Message: "请求参数错误"}
+	ErrContextLengthExceeded = &LLMError{Code: ErrCodeContextLengthExceeded, Message: "超出上下文长度"}
+	ErrContentFiltered       = &LLMError{Code: ErrCodeContentFiltered, Message: "内容被过滤"}
+	ErrOverloaded            = &LLMError{Code: ErrCodeOverloaded, Message: "服务过载"}
+	ErrTimeout               = &LLMError{Code: ErrCodeTimeout, Message: "请求超时"}
+	ErrServerError           = &LLMError{Code: ErrCodeServerError, Message: "服务端错误"}
+	ErrCancelled             = &LLMError{Code: ErrCodeCancelled, Message: "请求已取消"}
+)
+
+// Is 按错误码匹配，target 未设置 Provider 时匹配任意提供商
+// Is matches by error code; a target without Provider matches any provider
+func (e *LLMError) Is(target error) bool {
+	t, ok := target.(*LLMError)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.Provider == "" || t.Provider == e.Provider)
+}
+
+// Retryable 判断错误是否可以重试（限流、过载、超时和服务端错误）
+// Retryable reports whether the error is worth retrying (rate limits, overload, timeouts and server errors)
+func (e *LLMError) Retryable() bool {
+	switch e.Code {
+	case ErrCodeRateLimited, ErrCodeOverloaded, ErrCodeTimeout, ErrCodeServerError:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsRetryable 判断错误链中的 *LLMError 是否可以重试
+// IsRetryable reports whether the *LLMError in the error chain is retryable
+func IsRetryable(err error) bool {
+	var llmErr *LLMError
+	return errors.As(err, &llmErr) && llmErr.Retryable()
+}
+
+// newAPIError 创建 API 调用错误，从 OpenAI、Anthropic 和 genai SDK 的错误中提取
+// HTTP 状态码、请求ID、错误类型和重试间隔，并据此分类错误码
+// newAPIError creates an API call error, extracting the HTTP status, request ID, error type and retry delay
+// from OpenAI, Anthropic and genai SDK errors, and classifying the error code from them
+func newAPIError(provider ProviderType, message string, err error) *LLMError {
+	llmErr := NewLLMError(provider, ErrCodeAPI, message, err)
+
+	// detail 为提供商返回的错误码和错误信息，用于更细的分类
+	var detail string
+	var (
+		openaiErr    *openai.Error
+		anthropicErr *anthropic.Error
+		genaiErr     genai.APIError
+	)
+	switch {
+	case errors.As(err, &openaiErr):
+		llmErr.StatusCode = openaiErr.StatusCode
+		llmErr.Type = openaiErr.Type
+		detail = openaiErr.Code + " " + openaiErr.Message
+		if openaiErr.Response != nil {
+			llmErr.RequestID = openaiErr.Response.Header.Get("x-request-id")
+			llmErr.RetryAfter = parseRetryAfter(openaiErr.Response.Header)
+		}
+	case errors.As(err, &anthropicErr):
+		llmErr.StatusCode = anthropicErr.StatusCode
+		llmErr.RequestID = anthropicErr.RequestID
+		var body struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal([]byte(anthropicErr.RawJSON()), &body) == nil {
+			llmErr.Type = body.Error.Type
+			detail = body.Error.Message
+		}
+		if anthropicErr.Response != nil {
+			llmErr.RetryAfter = parseRetryAfter(anthropicErr.Response.Header)
+		}
+	case errors.As(err, &genaiErr):
+		llmErr.StatusCode = genaiErr.Code
+		llmErr.Type = genaiErr.Status
+		detail = genaiErr.Message
+		llmErr.RetryAfter = geminiRetryDelay(genaiErr.Details)
+	}
+
+	llmErr.Code = classifyAPIError(err, llmErr.StatusCode, llmErr.Type, detail)
+	return llmErr
+}
+
+// classifyAPIError 根据 HTTP 状态码、错误类型和错误信息确定错误码
+// classifyAPIError determines the error code from the HTTP status, error type and error detail
+func classifyAPIError(err error, status int, errType, detail string) string {
+	errType = strings.ToLower(errType)
+	text := errType + " " + strings.ToLower(detail)
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout():
+		return ErrCodeTimeout
+	case status < 500 && containsAny(text, "context_length_exceeded", "maximum context length", "prompt is too long",
+		"exceeds the maximum number of tokens", "input is too long"):
+		return ErrCodeContextLengthExceeded
+	case status < 500 && containsAny(text, "content_filter", "content_policy", "data_inspection_failed"):
+		return ErrCodeContentFiltered
+	case status == http.StatusUnauthorized || status == http.StatusForbidden ||
+		containsAny(errType, "authentication_error", "permission_error", "unauthenticated", "permission_denied"):
+		return ErrCodeAuth
+	// 额度用尽同样返回 429，但重试无法恢复，需要在限流之前判断
+	case status == http.StatusPaymentRequired || containsAny(text, "insufficient_quota", "billing"):
+		return ErrCodeQuotaExceeded
+	case status == http.StatusTooManyRequests || containsAny(errType, "rate_limit_error", "resource_exhausted"):
+		return ErrCodeRateLimited
+	case status == http.StatusServiceUnavailable || status == 529 || containsAny(errType, "overloaded_error", "unavailable"):
+		return ErrCodeOverloaded
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout || errType == "deadline_exceeded":
+		return ErrCodeTimeout
+	case status >= 500:
+		return ErrCodeServerError
+	case status >= 400:
+		return ErrCodeInvalidRequest
+	default:
+		return ErrCodeAPI
+	}
+}
+
+// containsAny 判断 s 是否包含任意一个子串
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRetryAfter 解析 retry-after-ms 和 Retry-After（秒数或 HTTP 日期）响应头
+// parseRetryAfter parses the retry-after-ms and Retry-After (seconds or HTTP date) headers
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	value := header.Get("Retry-After")
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// geminiRetryDelay 从 Gemini 错误详情的 google.rpc.RetryInfo 中读取重试间隔
+// geminiRetryDelay reads the retry delay from the google.rpc.RetryInfo entry of Gemini error details
+func geminiRetryDelay(details []map[string]any) time.Duration {
+	for _, detail := range details {
+		if kind, _ := detail["@type"].(string); !strings.HasSuffix(kind, "google.rpc.RetryInfo") {
+			continue
+		}
+		if delay, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(delay); err == nil {
+				return d
+			}
+		}
+	}
+	return 0
+}
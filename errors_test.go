@@ -0,0 +1,170 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newErrorServer 创建返回指定错误响应的测试服务器，并关闭 SDK 的自动重试
+// newErrorServer creates a test server replying with the given error, disabling the SDK's automatic retries
+func newErrorServer(t *testing.T, status int, headers map[string]string, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-should-retry", "false")
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewAPIError_OpenAI(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCode  string
+		sentinel  error
+		retryable bool
+	}{
+		{"rate limited", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, ErrCodeRateLimited, ErrRateLimited, true},
+		{"insufficient quota", 429, `{"error":{"message":"You exceeded your current quota, please check your plan and billing details","type":"insufficient_quota","code":"insufficient_quota"}}`, ErrCodeQuotaExceeded, ErrQuotaExceeded, false},
+		{"auth", 401, `{"error":{"message":"Incorrect API key","type":"invalid_request_error","code":"invalid_api_key"}}`, ErrCodeAuth, ErrAuth, false},
+		{"context length", 400, `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`, ErrCodeContextLengthExceeded, ErrContextLengthExceeded, false},
+		{"content filter", 400, `{"error":{"message":"The response was filtered","type":"invalid_request_error","code":"content_filter"}}`, ErrCodeContentFiltered, ErrContentFiltered, false},
+		{"invalid request", 400, `{"error":{"message":"Unknown parameter","type":"invalid_request_error","code":"unknown_parameter"}}`, ErrCodeInvalidRequest, ErrInvalidRequest, false},
+		{"overloaded", 503, `{"error":{"message":"The engine is currently overloaded","type":"server_error"}}`, ErrCodeOverloaded, ErrOverloaded, true},
+		{"server error", 500, `{"error":{"message":"internal error","type":"server_error"}}`, ErrCodeServerError, ErrServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newErrorServer(t, tt.status, map[string]string{"x-request-id": "req_123", "retry-after": "2"}, tt.body)
+			_, err := CreateOpenAI(URL(server.URL), APIKey("test")).Completion(context.Background(), &Input{Model: "m"})
+
+			var llmErr *LLMError
+			if !errors.As(err, &llmErr) {
+				t.Fatalf("Expected *LLMError, got %v", err)
+			}
+			if llmErr.Code != tt.wantCode || !errors.Is(err, tt.sentinel) || llmErr.Retryable() != tt.retryable {
+				t.Errorf("Expected code %s (retryable %v), got %s (retryable %v)", tt.wantCode, tt.retryable, llmErr.Code, llmErr.Retryable())
+			}
+			if llmErr.StatusCode != tt.status || llmErr.RequestID != "req_123" || llmErr.RetryAfter != 2*time.Second {
+				t.Errorf("Unexpected error details: status %d, request %q, retry after %v", llmErr.StatusCode, llmErr.RequestID, llmErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestNewAPIError_Anthropic(t *testing.T) {
+	server := newErrorServer(t, 529, map[string]string{"request-id": "req_456"},
+		`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	_, err := CreateAnthropic(URL(server.URL), APIKey("test"), MaxTokens(1024)).Completion(context.Background(), &Input{Model: "m"})
+
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) {
+		t.Fatalf("Expected *LLMError, got %v", err)
+	}
+	if !errors.Is(err, ErrOverloaded) || !IsRetryable(err) || llmErr.Provider != ProviderClaude {
+		t.Errorf("Expected retryable overloaded error, got %s", llmErr.Code)
+	}
+	if llmErr.Type != "overloaded_error" || llmErr.RequestID != "req_456" || llmErr.StatusCode != 529 {
+		t.Errorf("Unexpected error details: %+v", llmErr)
+	}
+}
+
+func TestNewAPIError_Gemini(t *testing.T) {
+	server := newErrorServer(t, 429, nil, `{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED",`+
+		`"details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"30s"}]}}`)
+	g, err := newGemini(context.Background(), URL(server.URL), APIKey("test"))
+	if err != nil {
+		t.Fatalf("newGemini() error = %v", err)
+	}
+	_, err = g.Completion(context.Background(), &Input{Messages: []Message{UserMessage("hi")}})
+
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) {
+		t.Fatalf("Expected *LLMError, got %v", err)
+	}
+	if !errors.Is(err, ErrRateLimited) || llmErr.Type != "RESOURCE_EXHAUSTED" || llmErr.RetryAfter != 30*time.Second {
+		t.Errorf("Unexpected error: %+v", llmErr)
+	}
+}
+
+func TestNewAPIError_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := CreateOpenAI(URL(server.URL), APIKey("test")).Completion(ctx, &Input{Model: "m"})
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestNewAPIError_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := CreateOpenAI(URL("http://127.0.0.1:1"), APIKey("test")).Completion(ctx, &Input{Model: "m"})
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) || IsRetryable(err) {
+		t.Errorf("Expected a non-retryable cancelled error, got %v", err)
+	}
+}
+
+func TestLLMError_Is(t *testing.T) {
+	err := fmt.Errorf("调用失败: %w", NewLLMError(ProviderOpenAI, ErrCodeRateLimited, "限流", nil))
+	if !errors.Is(err, ErrRateLimited) || errors.Is(err, ErrAuth) {
+		t.Errorf("Expected to match only ErrRateLimited")
+	}
+	if !errors.Is(err, &LLMError{Provider: ProviderOpenAI, Code: ErrCodeRateLimited}) || errors.Is(err, &LLMError{Provider: ProviderClaude, Code: ErrCodeRateLimited}) {
+		t.Errorf("Expected a target with Provider to match that provider only")
+	}
+	if IsRetryable(errors.New("other")) || IsRetryable(NewLLMError(ProviderOpenAI, "CONVERT_ERROR", "转换失败", nil)) {
+		t.Errorf("Expected non-API errors not to be retryable")
+	}
+}
+
+func TestIncompleteError_NilErr(t *testing.T) {
+	err := &IncompleteError{Output: &Output{Content: "部分"}}
+	if err.Error() != "流式响应未完成" || err.Unwrap() != nil || IsRetryable(err) {
+		t.Errorf("Expected an IncompleteError without a cause to be printable, got %q", err.Error())
+	}
+	wrapped := &IncompleteError{Err: ErrTimeout}
+	if !strings.Contains(wrapped.Error(), ErrTimeout.Error()) || !errors.Is(wrapped, ErrTimeout) {
+		t.Errorf("Expected the cause to be included, got %q", wrapped.Error())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{}, 0},
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{http.Header{"Retry-After": {"3"}, "Retry-After-Ms": {"1500"}}, 1500 * time.Millisecond},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header); got != tt.want {
+			t.Errorf("parseRetryAfter(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
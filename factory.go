@@ -0,0 +1,126 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ============================================================================
+// 提供商工厂 / Provider Factory
+// ============================================================================
+
+// Factory 根据配置创建 LLM 实例的工厂函数
+// Factory creates an LLM instance from the configuration
+// 参数:
+//   - cfg: 提供商配置 / Provider configuration
+//   - opts: 额外的配置选项，优先级高于 cfg / Extra options, taking precedence over cfg
+type Factory func(ctx context.Context, cfg Config, opts ...Option) (LLM, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[ProviderType]Factory{
+		ProviderOpenAI: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return CreateOpenAI(opts...), nil
+		},
+		ProviderOpenAIResponses: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return CreateOpenAIResponses(opts...), nil
+		},
+		ProviderAzure: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return CreateAzure(opts...), nil
+		},
+		ProviderClaude: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return CreateAnthropic(opts...), nil
+		},
+		ProviderGemini: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return newGemini(ctx, opts...)
+		},
+		// 腾讯混元提供 OpenAI 兼容端点 / Tencent Hunyuan exposes an OpenAI compatible endpoint
+		ProviderHunyuan: func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+			return CreateOpenAI(opts...), nil
+		},
+	}
+)
+
+// Register 注册提供商工厂，已存在的同名提供商会被覆盖；factory 为 nil 时 panic
+// Register registers a provider factory, replacing any existing factory for the provider; it panics if factory is nil
+func Register(provider ProviderType, factory Factory) {
+	if factory == nil {
+		panic("OpenLLM: Register factory is nil for provider " + string(provider))
+	}
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[provider] = factory
+}
+
+// Providers 返回已注册的提供商列表（按名称排序）
+// Providers returns the registered provider types, sorted by name
+func Providers() []ProviderType {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	providers := make([]ProviderType, 0, len(factories))
+	for provider := range factories {
+		providers = append(providers, provider)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+	return providers
+}
+
+// New 根据配置创建 LLM 实例
+// Config 中的 BaseURL、APIKey、Model 会转换为对应的 Option，opts 可以覆盖这些值
+// New creates an LLM instance from the configuration
+// BaseURL, APIKey and Model in Config are converted to options, which opts may override
+func New(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+	if cfg.Provider == "" {
+		return nil, NewLLMError(cfg.Provider, "CONFIG_ERROR", "未指定提供商", nil)
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[cfg.Provider]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, NewLLMError(cfg.Provider, "CONFIG_ERROR", "未注册的提供商", nil)
+	}
+
+	llm, err := factory(ctx, cfg, append(cfg.options(), opts...)...)
+	if err != nil {
+		var llmErr *LLMError
+		if errors.As(err, &llmErr) {
+			return nil, llmErr
+		}
+		return nil, NewLLMError(cfg.Provider, "CLIENT_ERROR", "创建客户端失败", err)
+	}
+	return llm, nil
+}
+
+// options 将配置转换为 Option 列表，空值不覆盖默认配置
+// options converts the configuration to options; empty values keep the defaults
+func (c Config) options() []Option {
+	var opts []Option
+	if c.BaseURL != "" {
+		opts = append(opts, URL(c.BaseURL))
+	}
+	if c.APIKey != "" {
+		opts = append(opts, APIKey(c.APIKey))
+	}
+	if c.Model != "" {
+		opts = append(opts, Model(c.Model))
+	}
+	return opts
+}
+
+// providerOf 返回 LLM 实例的提供商类型，未实现 Provider 方法时返回空值
+// providerOf returns the provider type of the LLM, or empty if it has no Provider method
+func providerOf(llm LLM) ProviderType {
+	return providerInfoOf(llm).Type
+}
+
+// providerInfoOf 返回 LLM 实例的提供商信息，未实现 Provider 方法时返回零值
+// providerInfoOf returns the provider information of the LLM, or the zero value if it has no Provider method
+func providerInfoOf(llm LLM) ProviderInfo {
+	if p, ok := llm.(interface{ Provider() ProviderInfo }); ok {
+		return p.Provider()
+	}
+	return ProviderInfo{}
+}
@@ -0,0 +1,85 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	llm, err := New(context.Background(), Config{Provider: ProviderAzure, APIKey: "test", Model: "gpt-5-mini"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	azure, ok := llm.(*Azure)
+	if !ok {
+		t.Fatalf("Expected *Azure, got %T", llm)
+	}
+	params, err := azure.GenerateAzureChatCompletionNewParams(&Input{Messages: []Message{UserMessage("hi")}})
+	if err != nil {
+		t.Fatalf("GenerateAzureChatCompletionNewParams() error = %v", err)
+	}
+	if params.Model != "gpt-5-mini" {
+		t.Errorf("Expected default model from config, got %s", params.Model)
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "empty provider", cfg: Config{}},
+		{name: "unknown provider", cfg: Config{Provider: "unknown"}},
+		{name: "gemini without api key", cfg: Config{Provider: ProviderGemini}},
+	}
+	t.Setenv("OpenLLM_API_KEY", "")
+	t.Setenv("GOOGLE_API_KEY", "")
+	t.Setenv("GEMINI_API_KEY", "")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(context.Background(), tt.cfg)
+			var llmErr *LLMError
+			if !errors.As(err, &llmErr) {
+				t.Fatalf("Expected *LLMError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	const provider ProviderType = "test_register"
+	var got Config
+	Register(provider, func(ctx context.Context, cfg Config, opts ...Option) (LLM, error) {
+		got = cfg
+		return CreateOpenAI(opts...), nil
+	})
+	defer func() {
+		factoriesMu.Lock()
+		delete(factories, provider)
+		factoriesMu.Unlock()
+	}()
+
+	if _, err := New(context.Background(), Config{Provider: provider, BaseURL: "http://localhost"}); err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if got.BaseURL != "http://localhost" {
+		t.Errorf("Expected factory to receive config, got %+v", got)
+	}
+}
+
+func TestRegister_NilFactory(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Register to panic on a nil factory")
+		}
+		factoriesMu.RLock()
+		_, ok := factories["test_nil"]
+		factoriesMu.RUnlock()
+		if ok {
+			t.Error("Expected the nil factory not to be registered")
+		}
+	}()
+	Register("test_nil", nil)
+}
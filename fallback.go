@@ -0,0 +1,180 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+)
+
+// ============================================================================
+// 提供商降级 / Provider Fallback
+// ============================================================================
+
+// Output.Extra 中记录实际回答的 LLM 的键
+// Output.Extra keys recording the LLM that answered
+const (
+	FallbackProviderKey = "fallback_provider" // 回答的提供商类型（ProviderType）/ Provider type that answered (ProviderType)
+	FallbackIndexKey    = "fallback_index"    // 回答的 LLM 序号，0 为主 LLM / Index of the LLM that answered, 0 is the primary
+)
+
+var (
+	_ LLM           = (*FallbackLLM)(nil)
+	_ EventStreamer = (*FallbackLLM)(nil)
+)
+
+// FallbackLLM 按顺序尝试多个 LLM，遇到可重试错误或 FallbackOn 指定的错误时切换到下一个
+// 流式调用只在发出第一个事件之前切换，避免重复输出
+// FallbackLLM tries several LLMs in order, moving to the next one on retryable errors or errors given to FallbackOn
+// Streaming calls only fall back before the first event has been emitted, so no output is duplicated
+type FallbackLLM struct {
+	llms []LLM
+	errs []error
+}
+
+// Fallback 创建降级链，primary 失败时依次尝试 fallbacks
+// 各 LLM 共用同一个 Input，需要不同模型名称时使用 MapModels 包装
+// Fallback creates a fallback chain trying fallbacks in order when primary fails
+// All LLMs share the same Input; wrap them with MapModels when they need different model names
+func Fallback(primary LLM, fallbacks ...LLM) *FallbackLLM {
+	return &FallbackLLM{llms: append([]LLM{primary}, fallbacks...)}
+}
+
+// FallbackOn 设置除可重试错误外同样触发降级的错误，按 errors.Is 匹配（如 ErrAuth、ErrContentFiltered）
+// FallbackOn sets errors that trigger a fallback in addition to retryable ones, matched with errors.Is
+// (such as ErrAuth or ErrContentFiltered)
+func (f *FallbackLLM) FallbackOn(errs ...error) *FallbackLLM {
+	f.errs = append(f.errs, errs...)
+	return f
+}
+
+// Completion 执行单次对话完成（非流式），失败时切换到下一个 LLM
+// Completion performs a single conversation completion (non-streaming), falling back to the next LLM on failure
+func (f *FallbackLLM) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	return f.do(ctx, func(llm LLM) (*Output, bool, error) {
+		output, err := llm.Completion(ctx, input, opts...)
+		return output, true, err
+	})
+}
+
+// CompletionStream 执行单次对话完成（流式），只在输出第一段文本之前切换
+// CompletionStream performs a single conversation completion (streaming), falling back only before any text is emitted
+func (f *FallbackLLM) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return f.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出，只在发出第一个事件之前切换
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events,
+// falling back only before the first event is emitted
+func (f *FallbackLLM) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	return f.do(ctx, func(llm LLM) (*Output, bool, error) {
+		emitted := false
+		output, err := CompletionStreamEvents(ctx, llm, input, func(event StreamEvent) {
+			emitted = true
+			handler.send(event)
+		}, opts...)
+		return output, !emitted, err
+	})
+}
+
+// Provider 返回主 LLM 的提供商信息
+// Provider returns the provider information of the primary LLM
+func (f *FallbackLLM) Provider() ProviderInfo {
+	return providerInfoOf(f.llms[0])
+}
+
+// do 依次调用各 LLM，直到成功或遇到不触发降级的错误；全部失败时返回所有错误
+// do calls each LLM in turn until one succeeds or fails with an error that does not trigger a fallback;
+// when all of them fail, every error is returned
+func (f *FallbackLLM) do(ctx context.Context, call func(LLM) (output *Output, fallback bool, err error)) (*Output, error) {
+	var errs []error
+	for index, llm := range f.llms {
+		output, fallback, err := call(llm)
+		if err == nil {
+			if output.Extra == nil {
+				output.Extra = make(map[string]any)
+			}
+			output.Extra[FallbackProviderKey] = providerOf(llm)
+			output.Extra[FallbackIndexKey] = index
+			return output, nil
+		}
+		if !fallback || !f.shouldFallback(err) || ctx.Err() != nil {
+			return output, err
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// shouldFallback 判断错误是否触发降级
+// shouldFallback reports whether the error triggers a fallback
+func (f *FallbackLLM) shouldFallback(err error) bool {
+	if IsRetryable(err) {
+		return true
+	}
+	for _, target := range f.errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// 模型名称映射 / Model Name Mapping
+// ============================================================================
+
+var (
+	_ LLM           = (*modelMapper)(nil)
+	_ EventStreamer = (*modelMapper)(nil)
+)
+
+// modelMapper 在调用前替换 Input.Model 的 LLM 包装
+// modelMapper is an LLM wrapper replacing Input.Model before each call
+type modelMapper struct {
+	llm    LLM
+	models map[string]string
+}
+
+// MapModels 包装 LLM，调用前按 models 将 Input.Model 替换为该提供商的模型名称，不在映射中的模型保持不变
+// 常用于 Fallback 中为每个提供商指定各自的模型
+// MapModels wraps an LLM, replacing Input.Model with the provider's model name from models before each call;
+// models missing from the mapping are left unchanged
+// It is typically used with Fallback to give every provider its own model
+func MapModels(llm LLM, models map[string]string) LLM {
+	return &modelMapper{llm: llm, models: models}
+}
+
+// Completion 执行单次对话完成（非流式）
+// Completion performs a single conversation completion (non-streaming)
+func (m *modelMapper) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	return m.llm.Completion(ctx, m.mapInput(input), opts...)
+}
+
+// CompletionStream 执行单次对话完成（流式）
+// CompletionStream performs a single conversation completion (streaming)
+func (m *modelMapper) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return m.llm.CompletionStream(ctx, m.mapInput(input), streamOutput, opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+func (m *modelMapper) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	return CompletionStreamEvents(ctx, m.llm, m.mapInput(input), handler, opts...)
+}
+
+// Provider 返回被包装 LLM 的提供商信息
+// Provider returns the provider information of the wrapped LLM
+func (m *modelMapper) Provider() ProviderInfo {
+	return providerInfoOf(m.llm)
+}
+
+// mapInput 返回替换了模型名称的 Input 副本，无需替换时返回原 Input
+// mapInput returns a copy of the input with the model replaced, or the input itself when no mapping applies
+func (m *modelMapper) mapInput(input *Input) *Input {
+	model, ok := m.models[input.Model]
+	if !ok {
+		return input
+	}
+	mapped := *input
+	mapped.Model = model
+	return &mapped
+}
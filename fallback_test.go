@@ -0,0 +1,78 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFallback_Completion(t *testing.T) {
+	azure := &mockLLM{provider: ProviderAzure, responses: []mockResponse{{err: NewLLMError(ProviderAzure, ErrCodeRateLimited, "限流", nil)}}}
+	openai := &mockLLM{provider: ProviderOpenAI, responses: []mockResponse{{err: NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)}}}
+	gemini := &mockLLM{provider: ProviderGemini, responses: []mockResponse{{output: &Output{Content: "你好"}}}}
+
+	llm := Fallback(azure, openai, MapModels(gemini, map[string]string{"gpt-4o": Gemini25Flash}))
+	output, err := llm.Completion(context.Background(), &Input{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	if output.Content != "你好" || output.Extra[FallbackProviderKey] != ProviderGemini || output.Extra[FallbackIndexKey] != 2 {
+		t.Errorf("Unexpected output: %+v", output)
+	}
+	if azure.inputs[0].Model != "gpt-4o" || gemini.inputs[0].Model != Gemini25Flash {
+		t.Errorf("Expected the model to be remapped for gemini only, got %s / %s", azure.inputs[0].Model, gemini.inputs[0].Model)
+	}
+	if llm.Provider().Type != ProviderAzure {
+		t.Errorf("Expected the primary provider, got %s", llm.Provider().Type)
+	}
+}
+
+func TestFallback_Errors(t *testing.T) {
+	authErr := NewLLMError(ProviderAzure, ErrCodeAuth, "认证失败", nil)
+
+	// 不触发降级的错误直接返回
+	primary := &mockLLM{responses: []mockResponse{{err: authErr}}}
+	secondary := &mockLLM{responses: []mockResponse{{output: &Output{Content: "你好"}}}}
+	if _, err := Fallback(primary, secondary).Completion(context.Background(), &Input{}); !errors.Is(err, ErrAuth) || len(secondary.inputs) != 0 {
+		t.Errorf("Expected the auth error without fallback, got %v", err)
+	}
+
+	// FallbackOn 指定的错误触发降级
+	output, err := Fallback(primary, secondary).FallbackOn(ErrAuth).Completion(context.Background(), &Input{})
+	if err != nil || output.Content != "你好" {
+		t.Errorf("Expected fallback on ErrAuth, got %v", err)
+	}
+
+	// 全部失败时返回所有错误
+	overloaded := NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)
+	_, err = Fallback(&mockLLM{responses: []mockResponse{{err: authErr}}}, &mockLLM{responses: []mockResponse{{err: overloaded}}}).
+		FallbackOn(ErrAuth).Completion(context.Background(), &Input{})
+	if !errors.Is(err, ErrAuth) || !errors.Is(err, ErrOverloaded) {
+		t.Errorf("Expected both errors, got %v", err)
+	}
+}
+
+func TestFallback_CompletionStream(t *testing.T) {
+	overloaded := NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)
+
+	// 已经输出内容后失败：不切换
+	primary := &mockLLM{responses: []mockResponse{{output: &Output{Content: "你"}, err: overloaded}}}
+	secondary := &mockLLM{responses: []mockResponse{{output: &Output{Content: "你好"}}}}
+	var chunks []string
+	_, err := Fallback(primary, secondary).CompletionStream(context.Background(), &Input{}, func(content string) {
+		chunks = append(chunks, content)
+	})
+	if !errors.Is(err, ErrOverloaded) || len(secondary.inputs) != 0 || len(chunks) != 1 {
+		t.Errorf("Expected no fallback after the first token, got %v, chunks %q", err, chunks)
+	}
+
+	// 首个事件之前失败：切换
+	primary = &mockLLM{responses: []mockResponse{{err: overloaded}}}
+	chunks = nil
+	output, err := Fallback(primary, secondary).CompletionStream(context.Background(), &Input{}, func(content string) {
+		chunks = append(chunks, content)
+	})
+	if err != nil || output.Content != "你好" || len(chunks) != 1 {
+		t.Errorf("Expected fallback before the first token, got %v, %v, %q", output, err, chunks)
+	}
+}
@@ -2,7 +2,13 @@ package OpenLLM
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"io"
+	"mime"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,26 +16,62 @@ import (
 	"google.golang.org/genai"
 )
 
+var (
+	_ LLM           = (*Gemini)(nil)
+	_ EventStreamer = (*Gemini)(nil)
+)
+
+// geminiToolCallIDPrefix Gemini API 通常不返回 function call ID，由本库生成的 ID 使用该前缀，回传时不再发送
+// geminiToolCallIDPrefix marks tool call IDs generated locally because the Gemini API often omits them
+const geminiToolCallIDPrefix = "gemini_call_"
+
+// geminiThoughtSignatureKey ToolCall.Metadata 中保存 thought signature 的键，值为 base64 字符串，JSON 序列化后保持不变
+// geminiThoughtSignatureKey is the ToolCall.Metadata key holding the Gemini thought signature as a base64 string,
+// so that it survives a JSON round trip
+const geminiThoughtSignatureKey = "gemini_thought_signature"
+
+// ============================================================================
+// Gemini SDK客户端封装 / Gemini SDK Client Wrapper
+// ============================================================================
+
+// Gemini 封装 Google Gemini 原生 SDK 客户端
+// Gemini wraps the native Google Gemini SDK client
 type Gemini struct {
 	options []Option
 	client  *genai.Client
 	once    sync.Once
 }
 
+// CreateGemini 创建 Gemini 客户端，创建失败时 panic；需要返回错误时请使用 New
+// CreateGemini creates a new Gemini client and panics on failure; use New to get an error instead
 func CreateGemini(ctx context.Context, opts ...Option) *Gemini {
+	gemini, err := newGemini(ctx, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return gemini
+}
+
+// newGemini 创建 Gemini 客户端并返回错误
+// newGemini creates a new Gemini client and returns any error
+func newGemini(ctx context.Context, opts ...Option) (*Gemini, error) {
 	options := newOptions(opts)
-	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+	config := &genai.ClientConfig{
 		APIKey:     options.APIKey,
 		Backend:    genai.BackendGeminiAPI,
-		HTTPClient: requests.New().HTTPClient(options.HTTPClientOptions...),
-	})
+		HTTPClient: requests.New().HTTPClient(options.httpClientOptions()...),
+	}
+	if options.URL != "" {
+		config.HTTPOptions.BaseURL = options.URL
+	}
+	client, err := genai.NewClient(ctx, config)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	return &Gemini{
 		options: opts,
 		client:  client,
-	}
+	}, nil
 }
 
 // extractThinkingContent 从响应中提取 thinking 内容
@@ -45,130 +87,423 @@ func extractThinkingContent(candidates []*genai.Candidate) string {
 			thinkingParts = append(thinkingParts, part.Text)
 		}
 	}
-
-	if len(thinkingParts) == 0 {
-		return ""
-	}
-	return thinkingParts[0] // 通常只有一个 thinking part，如果有多个则合并
+	return strings.Join(thinkingParts, "")
 }
 
-func (g *Gemini) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
-	// options := newOptions(opts, g.options...)
-	output := &Output{StartAt: time.Now()}
-	result, err := g.client.Models.GenerateContent(
-		ctx,
-		"gemini-2.5-flash",
-		genai.Text(input.Messages[0].Content),
-		&genai.GenerateContentConfig{
-			ThinkingConfig: &genai.ThinkingConfig{
-				IncludeThoughts: true,
-				ThinkingLevel:   genai.ThinkingLevelLow,
-			},
-			Tools: []*genai.Tool{
-				{
-					FunctionDeclarations: []*genai.FunctionDeclaration{
-						{
-							Name:        "get_weather",
-							Description: "Get the weather of a city",
-							Parameters: &genai.Schema{
-								Type: "object",
-								Properties: map[string]*genai.Schema{
-									"city": {
-										Type: "string",
-									},
-								},
-								Required: []string{"city"},
-							},
-						},
-					},
-				},
-			},
-		},
-	)
+// ============================================================================
+// LLM接口实现 / LLM Interface Implementation
+// ============================================================================
 
+// Completion 执行单次对话完成（非流式）
+// Completion performs a single conversation completion (non-streaming)
+func (g *Gemini) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	contents, config, err := g.GenerateGeminiContents(input, opts...)
 	if err != nil {
-		return nil, err
+		return nil, NewLLMError(ProviderGemini, "CONVERT_ERROR", "转换请求参数失败", err)
 	}
 
-	// 提取 thinking 内容 / Extract thinking content
-	thinking := extractThinkingContent(result.Candidates)
+	startTime := time.Now()
+	result, err := g.client.Models.GenerateContent(ctx, g.geminiModel(input, opts...), contents, config)
+	if err != nil {
+		return nil, newAPIError(ProviderGemini, "Gemini API调用失败", err)
+	}
+	if len(result.Candidates) == 0 {
+		return nil, NewLLMError(ProviderGemini, "EMPTY_RESPONSE", "Gemini返回空响应", nil)
+	}
 
-	if len(result.Candidates) > 0 {
-		for _, part := range result.Candidates[0].Content.Parts {
+	output := &Output{StartAt: startTime, RawResponse: result}
+	if content := result.Candidates[0].Content; content != nil {
+		for _, part := range content.Parts {
 			if part.FunctionCall != nil {
-				output.ToolCalls = append(output.ToolCalls, ToolCall{
-					ID:        part.FunctionCall.ID,
-					Name:      part.FunctionCall.Name,
-					Arguments: part.FunctionCall.Args,
-				})
+				output.ToolCalls = append(output.ToolCalls, fromGeminiFunctionCall(part))
 			}
 		}
 	}
 
 	output.Content = result.Text()
-	output.Thinking = thinking
-	output.FinishReason = string(result.Candidates[0].FinishReason)
-	output.TokenUsage = TokenUsage{
-		InputTokens:    int64(result.UsageMetadata.PromptTokenCount),
-		ThinkingTokens: int64(result.UsageMetadata.ThoughtsTokenCount),
-		OutputTokens:   int64(result.UsageMetadata.CandidatesTokenCount + result.UsageMetadata.ThoughtsTokenCount),
-		TotalTokens:    int64(result.UsageMetadata.TotalTokenCount),
-	}
-	return output, nil
+	output.Thinking = extractThinkingContent(result.Candidates)
+	output.FinishReason = fromGeminiFinishReason(result.Candidates[0].FinishReason, len(output.ToolCalls) > 0)
+	output.TokenUsage = fromGeminiUsage(result.UsageMetadata)
+	output.Cost = time.Since(startTime)
+	return validateStructuredOutput(ProviderGemini, input, output)
 }
 
+// Int32 返回 int32 指针
+// Int32 returns a pointer to the int32 value
 func Int32(value int) *int32 {
 	v := int32(value)
 	return &v
 }
 
+// CompletionStream 执行单次对话完成（流式）
+// CompletionStream performs a single conversation completion (streaming)
 func (g *Gemini) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
-	// options := newOptions(opts)
-
-	response := g.client.Models.GenerateContentStream(ctx, "gemini-2.5-flash",
-		genai.Text(input.Messages[0].Content),
-		&genai.GenerateContentConfig{
-			ThinkingConfig: &genai.ThinkingConfig{
-				IncludeThoughts: true, // 启用 thinking 内容 / Enable thinking content
-			},
-		},
-	)
+	return g.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// Gemini 的工具调用不是增量返回的，每个工具调用对应一个包含完整参数的增量事件
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+// Gemini returns whole function calls, so each tool call is emitted as a single delta carrying the full arguments
+func (g *Gemini) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	contents, config, err := g.GenerateGeminiContents(input, opts...)
+	if err != nil {
+		return nil, NewLLMError(ProviderGemini, "CONVERT_ERROR", "转换请求参数失败", err)
+	}
 
 	output := &Output{StartAt: time.Now()}
+	response := g.client.Models.GenerateContentStream(ctx, g.geminiModel(input, opts...), contents, config)
+
+	// 流中断时返回已收到的部分输出 / Return the partial output when the stream is interrupted
+	recorder := newStreamRecorder()
+	handler = recorder.wrap(handler)
 
+	var finishReason genai.FinishReason
 	for chunk, err := range response {
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
-			return nil, err
+			return recorder.interrupt(ctx, handler, newAPIError(ProviderGemini, "Gemini API调用失败", err))
 		}
 		if len(chunk.Candidates) > 0 && chunk.Candidates[0].Content != nil {
-			// 遍历所有 parts，区分 thinking 内容和普通内容
-			// Iterate through all parts, distinguish between thinking content and normal content
+			// 遍历所有 parts，区分 thinking 内容、工具调用和普通内容
+			// Iterate through all parts, distinguish between thinking content, function calls and normal content
 			for _, part := range chunk.Candidates[0].Content.Parts {
+				if part.FunctionCall != nil {
+					toolCall := fromGeminiFunctionCall(part)
+					arguments, _ := json.Marshal(toolCall.Arguments)
+					handler.toolCall(ToolCallDelta{
+						Index:     len(output.ToolCalls),
+						ID:        toolCall.ID,
+						Name:      toolCall.Name,
+						Arguments: string(arguments),
+					})
+					output.ToolCalls = append(output.ToolCalls, toolCall)
+					continue
+				}
 				if part.Text == "" {
 					continue
 				}
 				if part.Thought {
 					// thinking 内容累积到 Thinking 字段 / Accumulate thinking content to Thinking field
 					output.Thinking += part.Text
-					streamOutput(part.Text)
+					handler.thinking(part.Text)
 				} else {
-					// 普通内容累积到 Content 字段并流式输出 / Accumulate normal content to Content field and stream output
+					// 普通内容累积到 Content 字段 / Accumulate normal content to Content field
 					output.Content += part.Text
-					streamOutput(part.Text)
+					handler.content(part.Text)
 				}
 			}
 		}
-		if len(chunk.Candidates) > 0 {
-			output.FinishReason = string(chunk.Candidates[0].FinishReason)
+		if len(chunk.Candidates) > 0 && chunk.Candidates[0].FinishReason != "" {
+			finishReason = chunk.Candidates[0].FinishReason
+		}
+		// 流式响应中的 usage 是累计值，以最后一次为准 / Streaming usage is cumulative, keep the latest
+		if chunk.UsageMetadata != nil {
+			output.TokenUsage = fromGeminiUsage(chunk.UsageMetadata)
 		}
-		output.TokenUsage.InputTokens += int64(chunk.UsageMetadata.PromptTokenCount)
-		output.TokenUsage.ThinkingTokens += int64(chunk.UsageMetadata.ThoughtsTokenCount)
-		output.TokenUsage.OutputTokens += int64(chunk.UsageMetadata.CandidatesTokenCount + chunk.UsageMetadata.ThoughtsTokenCount)
-		output.TokenUsage.TotalTokens += int64(chunk.UsageMetadata.TotalTokenCount)
+		output.RawResponse = chunk
 	}
+	output.FinishReason = fromGeminiFinishReason(finishReason, len(output.ToolCalls) > 0)
 	output.Cost = time.Since(output.StartAt)
-	return output, nil
+	recorder.finish(handler, output)
+	return validateStructuredOutput(ProviderGemini, input, output)
+}
+
+// Provider 获取提供商信息
+// Provider returns the provider information
+func (g *Gemini) Provider() ProviderInfo {
+	return providerInfo(g.options, ProviderInfo{
+		Type:       ProviderGemini,
+		Name:       "Google Gemini",
+		parameters: g.requestParameters,
+	}, Model(g.geminiModel(&Input{})))
+}
+
+// requestParameters 返回请求实际发送的生成参数，采样参数记录转换为 float32 之前的值
+// requestParameters returns the generation parameters actually sent with the request, recording the sampling
+// parameters before their conversion to float32
+func (g *Gemini) requestParameters(input *Input, stream bool, opts []Option) requestParameters {
+	_, config, err := g.GenerateGeminiContents(&Input{Model: input.Model}, opts...)
+	if err != nil {
+		return requestParameters{}
+	}
+	options := newOptions(g.options, opts...)
+	var params requestParameters
+	if config.Temperature != nil {
+		params.Temperature = &options.Temperature
+	}
+	if config.TopP != nil {
+		params.TopP = &options.TopP
+	}
+	if config.MaxOutputTokens > 0 {
+		maxTokens := int64(config.MaxOutputTokens)
+		params.MaxTokens = &maxTokens
+	}
+	return params
+}
+
+// ============================================================================
+// 适配逻辑 / Adapter Logic
+// 将Union类型转换为SDK原生类型，或将SDK原生类型转换为Union类型
+// ============================================================================
+
+// geminiModel 返回请求使用的模型，未指定时使用 Gemini25Flash
+// geminiModel returns the model to request, defaulting to Gemini25Flash
+func (g *Gemini) geminiModel(input *Input, opts ...Option) string {
+	if model := newOptions(g.options, opts...).modelName(input); model != "" {
+		return model
+	}
+	return Gemini25Flash
+}
+
+// GenerateGeminiContents 将Union请求转换为Gemini SDK原生的对话内容和生成配置
+// GenerateGeminiContents converts Union request to Gemini SDK native contents and generation config
+func (g *Gemini) GenerateGeminiContents(input *Input, opts ...Option) ([]*genai.Content, *genai.GenerateContentConfig, error) {
+	options := newOptions(g.options, opts...)
+
+	config := &genai.GenerateContentConfig{
+		Temperature:     genai.Ptr(float32(options.Temperature)),
+		MaxOutputTokens: geminiMaxOutputTokens(options, g.geminiModel(input, opts...)),
+		Seed:            genai.Ptr(int32(options.Seed)),
+		ThinkingConfig: &genai.ThinkingConfig{
+			IncludeThoughts: true, // 启用 thinking 内容 / Enable thinking content
+		},
+	}
+	if options.TopP > 0 {
+		config.TopP = genai.Ptr(float32(options.TopP))
+	}
+
+	// 1. 转换消息：系统消息放入 SystemInstruction，其余消息按角色合并
+	// 工具结果只携带 ToolCallID，需要根据之前的 assistant 工具调用找回函数名
+	toolNames := make(map[string]string)
+	contents := make([]*genai.Content, 0, len(input.Messages))
+	for _, msg := range input.Messages {
+		if msg.Role == RoleSystem {
+			if config.SystemInstruction == nil {
+				config.SystemInstruction = &genai.Content{}
+			}
+			config.SystemInstruction.Parts = append(config.SystemInstruction.Parts, genai.NewPartFromText(msg.Text()))
+			continue
+		}
+		for _, tc := range msg.ToolCalls {
+			toolNames[tc.ID] = tc.Name
+		}
+
+		content, err := toGeminiContent(msg, toolNames)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 并行工具调用的多个结果必须放在同一轮中
+		if n := len(contents); n > 0 && contents[n-1].Role == content.Role {
+			contents[n-1].Parts = append(contents[n-1].Parts, content.Parts...)
+			continue
+		}
+		contents = append(contents, content)
+	}
+
+	// 2. 转换工具定义
+	if len(input.Tools) > 0 {
+		tool := &genai.Tool{}
+		for _, t := range input.Tools {
+			declaration, err := t.ToGeminiFunctionDeclaration()
+			if err != nil {
+				return nil, nil, fmt.Errorf("转换工具 %s 失败: %w", t.Name, err)
+			}
+			tool.FunctionDeclarations = append(tool.FunctionDeclarations, declaration)
+		}
+		config.Tools = []*genai.Tool{tool}
+	}
+
+	// 3. 转换工具选择策略
+	if input.ToolChoice != nil {
+		config.ToolConfig = toGeminiToolConfig(*input.ToolChoice)
+	}
+
+	// 4. 结构化输出
+	if format := input.ResponseFormat; format != nil {
+		if err := format.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("结构化输出格式验证失败: %w", err)
+		}
+		config.ResponseMIMEType = "application/json"
+		// 含 map 类型时通过 ResponseJsonSchema 发送完整的 JSON Schema / Schemas with maps are sent in full through ResponseJsonSchema
+		if format.Schema.SupportsOpenAIStrict() {
+			config.ResponseSchema = format.Schema.ToGeminiSchema()
+		} else {
+			config.ResponseJsonSchema = map[string]any(format.Schema.ToOpenAIParameters())
+		}
+	}
+
+	return contents, config, nil
+}
+
+// geminiMaxOutputTokens 返回发送给 Gemini 的最大输出 token 数：未显式设置时为 0，使用模型自身的默认值；
+// 超过模型目录中的上限时按上限发送
+// geminiMaxOutputTokens returns the maximum output tokens sent to Gemini: 0 when it was not set explicitly, leaving
+// the model default in place, and capped at the limit from the model catalog
+func geminiMaxOutputTokens(options *Options, model string) int32 {
+	maxTokens, ok := options.maxTokens()
+	if !ok {
+		return 0
+	}
+	return int32(capMaxTokens(maxTokens, model))
+}
+
+// toGeminiContent 将Message消息转换为Gemini对话内容
+// toGeminiContent converts Message message to Gemini content
+func toGeminiContent(msg Message, toolNames map[string]string) (*genai.Content, error) {
+	switch msg.Role {
+	case RoleUser:
+		if len(msg.Parts) == 0 {
+			return genai.NewContentFromText(msg.Content, genai.RoleUser), nil
+		}
+		parts, err := toGeminiParts(msg.Parts)
+		if err != nil {
+			return nil, err
+		}
+		return genai.NewContentFromParts(parts, genai.RoleUser), nil
+
+	case RoleAssistant:
+		var parts []*genai.Part
+		if text := msg.Text(); text != "" {
+			parts = append(parts, genai.NewPartFromText(text))
+		}
+		for _, tc := range msg.ToolCalls {
+			part := genai.NewPartFromFunctionCall(tc.Name, tc.Arguments)
+			if !strings.HasPrefix(tc.ID, geminiToolCallIDPrefix) {
+				part.FunctionCall.ID = tc.ID
+			}
+			if encoded, ok := tc.Metadata[geminiThoughtSignatureKey].(string); ok {
+				signature, err := base64.StdEncoding.DecodeString(encoded)
+				if err != nil {
+					return nil, fmt.Errorf("工具调用 %s 的 thought signature 无效: %w", tc.ID, err)
+				}
+				part.ThoughtSignature = signature
+			}
+			parts = append(parts, part)
+		}
+		return genai.NewContentFromParts(parts, genai.RoleModel), nil
+
+	case RoleTool:
+		name := msg.Name
+		if name == "" {
+			name = toolNames[msg.ToolCallID]
+		}
+		if name == "" {
+			return nil, fmt.Errorf("工具结果 %s 找不到对应的工具调用", msg.ToolCallID)
+		}
+		// Gemini 约定用 "output" 返回结果、"error" 返回错误 / Gemini uses "output" for results and "error" for failures
+		response := map[string]any{"output": msg.Text()}
+		if msg.IsError {
+			response = map[string]any{"error": msg.Text()}
+		}
+		part := genai.NewPartFromFunctionResponse(name, response)
+		if !strings.HasPrefix(msg.ToolCallID, geminiToolCallIDPrefix) {
+			part.FunctionResponse.ID = msg.ToolCallID
+		}
+		// Gemini 中函数结果以 user 轮次回传
+		return genai.NewContentFromParts([]*genai.Part{part}, genai.RoleUser), nil
+
+	default:
+		return nil, fmt.Errorf("不支持的消息角色: %s", msg.Role)
+	}
+}
+
+// toGeminiParts 将多模态内容片段转换为Gemini内容片段
+// toGeminiParts converts multimodal content parts to Gemini parts
+func toGeminiParts(parts []ContentPart) ([]*genai.Part, error) {
+	result := make([]*genai.Part, 0, len(parts))
+	for _, part := range parts {
+		switch {
+		case part.Type == PartText:
+			result = append(result, genai.NewPartFromText(part.Text))
+
+		case len(part.Data) > 0:
+			// 图片、音频、文件的内联数据统一使用 inline_data
+			result = append(result, genai.NewPartFromBytes(part.Data, part.MIMEType))
+
+		case part.URL != "":
+			mimeType := part.MIMEType
+			if mimeType == "" {
+				mimeType = mime.TypeByExtension(path.Ext(part.URL))
+			}
+			if mimeType == "" {
+				return nil, fmt.Errorf("无法确定 %s 的MIME类型", part.URL)
+			}
+			result = append(result, genai.NewPartFromURI(part.URL, mimeType))
+
+		default:
+			return nil, fmt.Errorf("Gemini 不支持的内容片段类型: %s", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// toGeminiToolConfig 将Tool工具选择转换为Gemini格式
+// toGeminiToolConfig converts Union tool choice to Gemini format
+func toGeminiToolConfig(choice ToolChoiceOption) *genai.ToolConfig {
+	config := &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAuto}
+	switch choice.Type {
+	case ToolChoiceRequired:
+		config.Mode = genai.FunctionCallingConfigModeAny
+	case ToolChoiceNone:
+		config.Mode = genai.FunctionCallingConfigModeNone
+	case ToolChoiceSpecific:
+		config.Mode = genai.FunctionCallingConfigModeAny
+		config.AllowedFunctionNames = []string{choice.ToolName}
+	}
+	return &genai.ToolConfig{FunctionCallingConfig: config}
+}
+
+// fromGeminiFunctionCall 将Gemini函数调用转换为Union工具调用
+// fromGeminiFunctionCall converts Gemini function call to Union tool call
+func fromGeminiFunctionCall(part *genai.Part) ToolCall {
+	call := ToolCall{
+		ID:        part.FunctionCall.ID,
+		Name:      part.FunctionCall.Name,
+		Arguments: part.FunctionCall.Args,
+	}
+	if call.ID == "" {
+		call.ID = geminiToolCallIDPrefix + requests.GenId()
+	}
+	if len(part.ThoughtSignature) > 0 {
+		call.Metadata = map[string]any{geminiThoughtSignatureKey: base64.StdEncoding.EncodeToString(part.ThoughtSignature)}
+	}
+	return call
+}
+
+// fromGeminiFinishReason 将Gemini结束原因转换为Union结束原因
+// fromGeminiFinishReason converts Gemini finish reason to Union finish reason
+func fromGeminiFinishReason(reason genai.FinishReason, hasToolCalls bool) string {
+	// Gemini 在返回函数调用时仍然使用 STOP，这里统一为 tool_calls
+	if hasToolCalls {
+		return string(FinishReasonToolCalls)
+	}
+	switch reason {
+	case genai.FinishReasonStop:
+		return string(FinishReasonStop)
+	case genai.FinishReasonMaxTokens:
+		return string(FinishReasonLength)
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation, genai.FinishReasonBlocklist,
+		genai.FinishReasonProhibitedContent, genai.FinishReasonSPII:
+		return string(FinishReasonContentFilter)
+	default:
+		return string(reason)
+	}
+}
+
+// fromGeminiUsage 将Gemini用量统计转换为Union格式
+// fromGeminiUsage converts Gemini usage metadata to Union format
+func fromGeminiUsage(usage *genai.GenerateContentResponseUsageMetadata) TokenUsage {
+	if usage == nil {
+		return TokenUsage{}
+	}
+	return TokenUsage{
+		InputTokens:    int64(usage.PromptTokenCount),
+		CachedTokens:   int64(usage.CachedContentTokenCount),
+		ThinkingTokens: int64(usage.ThoughtsTokenCount),
+		OutputTokens:   int64(usage.CandidatesTokenCount + usage.ThoughtsTokenCount),
+		TotalTokens:    int64(usage.TotalTokenCount),
+	}
 }
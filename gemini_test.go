@@ -1,13 +1,16 @@
 package OpenLLM
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/golang-io/requests"
+	"google.golang.org/genai"
 )
 
 func Test_CreateGemini(t *testing.T) {
@@ -47,3 +50,215 @@ func Test_Gemini_Completion_FromOpenAI(t *testing.T) {
 
 	t.Logf("%#v, err=%v", output, err)
 }
+
+func TestGemini_GenerateGeminiContents(t *testing.T) {
+	gemini := &Gemini{}
+	contents, config, err := gemini.GenerateGeminiContents(&Input{
+		Model: Gemini25Flash,
+		Messages: []Message{
+			SystemMessage("你是一个天气助手"),
+			UserMessage("查询北京的天气和时间"),
+			AssistantMessageWithTools("", []ToolCall{
+				{ID: "gemini_call_1", Name: "query_weather", Arguments: map[string]any{"city": "beijing"}},
+				{ID: "call_2", Name: "current_time", Arguments: map[string]any{"tz": "Asia/Shanghai"}},
+			}),
+			ToolMessage("晴", "gemini_call_1"),
+			ToolMessage("2025-01-01 10:00:00", "call_2"),
+		},
+		Tools:      Tools,
+		ToolChoice: &ToolChoiceOption{Type: ToolChoiceSpecific, ToolName: "query_weather"},
+	}, Temperature(0.5), MaxTokens(1024), Seed(7))
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+
+	if config.SystemInstruction == nil || config.SystemInstruction.Parts[0].Text != "你是一个天气助手" {
+		t.Errorf("Expected system instruction, got %+v", config.SystemInstruction)
+	}
+	if *config.Temperature != 0.5 || config.MaxOutputTokens != 1024 || *config.Seed != 7 {
+		t.Errorf("Expected options applied, got temperature=%v max=%d seed=%v", *config.Temperature, config.MaxOutputTokens, *config.Seed)
+	}
+	if config.TopP != nil {
+		t.Errorf("Expected top_p to be omitted, got %v", *config.TopP)
+	}
+
+	// user → model(function_call×2) → user(function_response×2)
+	if len(contents) != 3 {
+		t.Fatalf("Expected 3 contents, got %d", len(contents))
+	}
+	if contents[1].Role != "model" || len(contents[1].Parts) != 2 {
+		t.Errorf("Expected model turn with 2 function calls, got %+v", contents[1])
+	}
+	if id := contents[1].Parts[0].FunctionCall.ID; id != "" {
+		t.Errorf("Expected locally generated ID to be stripped, got %q", id)
+	}
+	responses := contents[2].Parts
+	if len(responses) != 2 || responses[0].FunctionResponse.Name != "query_weather" || responses[1].FunctionResponse.ID != "call_2" {
+		t.Errorf("Expected function responses matched to calls, got %+v, %+v", responses[0].FunctionResponse, responses[1].FunctionResponse)
+	}
+
+	if len(config.Tools) != 1 || len(config.Tools[0].FunctionDeclarations) != len(Tools) {
+		t.Errorf("Expected %d function declarations, got %+v", len(Tools), config.Tools)
+	}
+	if fc := config.ToolConfig.FunctionCallingConfig; fc.Mode != "ANY" || fc.AllowedFunctionNames[0] != "query_weather" {
+		t.Errorf("Expected specific tool config, got %+v", fc)
+	}
+}
+
+func TestGemini_ToolMessageWithoutCall(t *testing.T) {
+	gemini := &Gemini{}
+	_, _, err := gemini.GenerateGeminiContents(&Input{
+		Messages: []Message{ToolMessage("晴", "unknown")},
+	})
+	if err == nil {
+		t.Error("Expected error for tool result without matching tool call")
+	}
+}
+
+func TestGemini_MapSchema(t *testing.T) {
+	type tagged struct {
+		Name string            `json:"name"`
+		Tags map[string]string `json:"tags"`
+	}
+	schema, err := SchemaFor(tagged{})
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+
+	input := &Input{
+		Model:          Gemini25Flash,
+		Messages:       []Message{UserMessage("打标签")},
+		Tools:          []Tool{{Name: "tag", Description: "添加标签", Parameters: schema}},
+		ResponseFormat: &ResponseFormat{Name: "tagged", Schema: schema},
+	}
+	_, config, err := (&Gemini{}).GenerateGeminiContents(input)
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+
+	// map 字段通过完整的 JSON Schema 发送，保留值的类型
+	declaration := config.Tools[0].FunctionDeclarations[0]
+	for name, sent := range map[string]any{"parameters": declaration.ParametersJsonSchema, "response": config.ResponseJsonSchema} {
+		params, ok := sent.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected %s to be sent as JSON Schema, got %T", name, sent)
+		}
+		tags := params["properties"].(map[string]any)["tags"].(map[string]any)
+		if tags["additionalProperties"].(map[string]any)["type"] != "string" {
+			t.Errorf("Expected the map value schema in %s, got %v", name, tags)
+		}
+	}
+	if declaration.Parameters != nil || config.ResponseSchema != nil {
+		t.Error("Expected the Gemini Schema to be left unset for a map field")
+	}
+
+	// 不含 map 的 Schema 仍使用 Gemini Schema
+	_, config, err = (&Gemini{}).GenerateGeminiContents(&Input{Messages: []Message{UserMessage("天气")}, Tools: Tools, ResponseFormat: weatherFormat})
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+	if config.ResponseSchema == nil || config.ResponseJsonSchema != nil || config.Tools[0].FunctionDeclarations[0].Parameters == nil {
+		t.Error("Expected schemas without maps to use the Gemini Schema")
+	}
+}
+
+func TestGemini_ToolErrorMessage(t *testing.T) {
+	contents, _, err := (&Gemini{}).GenerateGeminiContents(&Input{
+		Messages: []Message{
+			UserMessage("北京天气和时间"),
+			AssistantMessageWithTools("", []ToolCall{
+				{ID: "call_1", Name: "query_weather", Arguments: map[string]any{"city": "北京"}},
+				{ID: "call_2", Name: "current_time", Arguments: map[string]any{}},
+			}),
+			ToolMessage("晴", "call_1"),
+			ToolErrorMessage("工具 current_time 执行失败: 超时", "call_2"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+
+	// 失败的工具结果以 error 字段回传 / Failed tool results are sent in the error field
+	responses := contents[2].Parts
+	if responses[0].FunctionResponse.Response["output"] != "晴" || responses[1].FunctionResponse.Response["error"] != "工具 current_time 执行失败: 超时" {
+		t.Errorf("Expected output and error responses, got %v, %v", responses[0].FunctionResponse.Response, responses[1].FunctionResponse.Response)
+	}
+	if _, ok := responses[1].FunctionResponse.Response["output"]; ok {
+		t.Errorf("Expected no output for a failed tool, got %v", responses[1].FunctionResponse.Response)
+	}
+}
+
+func TestGemini_MaxOutputTokens(t *testing.T) {
+	input := &Input{Model: "gemini-2.5-pro", Messages: []Message{UserMessage("你好")}}
+	tests := []struct {
+		name string
+		opts []Option
+		want int32
+	}{
+		{"默认值不发送", nil, 0},
+		{"显式设置", []Option{MaxTokens(2048)}, 2048},
+		{"显式设置与默认值相同", []Option{MaxTokens(defaultMaxTokens)}, 65536},
+		{"不大于0不发送", []Option{MaxTokens(0)}, 0},
+		{"按模型上限截断", []Option{MaxTokens(1 << 20)}, 65536},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, config, err := (&Gemini{}).GenerateGeminiContents(input, tt.opts...)
+			if err != nil {
+				t.Fatalf("GenerateGeminiContents() error = %v", err)
+			}
+			if config.MaxOutputTokens != tt.want {
+				t.Errorf("Expected max output tokens %d, got %d", tt.want, config.MaxOutputTokens)
+			}
+		})
+	}
+}
+
+func TestGemini_ThoughtSignatureRoundTrip(t *testing.T) {
+	signature := []byte{0x01, 0xfe, 's', 'i', 'g'}
+	part := genai.NewPartFromFunctionCall("query_weather", map[string]any{"city": "北京"})
+	part.ThoughtSignature = signature
+	call := fromGeminiFunctionCall(part)
+
+	// 持久化对话历史后再继续对话 / Persist the conversation history, then continue it
+	history, err := json.Marshal([]Message{UserMessage("北京天气"), AssistantMessageWithTools("", []ToolCall{call}), ToolMessage("晴", call.ID)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var messages []Message
+	if err := json.Unmarshal(history, &messages); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	contents, _, err := (&Gemini{}).GenerateGeminiContents(&Input{Messages: messages})
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+	if got := contents[1].Parts[0].ThoughtSignature; !bytes.Equal(got, signature) {
+		t.Errorf("Expected the thought signature to survive a JSON round trip, got %v", got)
+	}
+}
+
+func TestGemini_MultimodalMessage(t *testing.T) {
+	g := &Gemini{}
+	contents, _, err := g.GenerateGeminiContents(&Input{
+		Messages: []Message{UserMessageWithParts(
+			TextPart("这是什么?"),
+			ImagePart([]byte("png"), "image/png"),
+			FileURLPart("gs://bucket/a.pdf", ""),
+		)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+	parts := contents[0].Parts
+	if len(parts) != 3 {
+		t.Fatalf("Expected 3 parts, got %d", len(parts))
+	}
+	if parts[1].InlineData == nil || parts[1].InlineData.MIMEType != "image/png" {
+		t.Errorf("Expected inline image, got %+v", parts[1])
+	}
+	if parts[2].FileData == nil || parts[2].FileData.MIMEType != "application/pdf" {
+		t.Errorf("Expected file data with guessed MIME type, got %+v", parts[2])
+	}
+}
@@ -2,6 +2,8 @@ package OpenLLM
 
 import (
 	"context"
+	"encoding/base64"
+	"strings"
 	"time"
 )
 
@@ -47,6 +49,13 @@ type ProviderInfo struct {
 	BaseURL string       `json:"base_url"` // API基础URL / API base URL
 	// 能力标识 / Capability flags
 	Capabilities ProviderCapabilities `json:"capabilities"`
+
+	// options 客户端级别的配置选项，中间件与单次调用的选项合并得到实际生效的选项
+	// options are the client-level options, merged with the per-call options by middlewares to get the effective options
+	options []Option
+	// parameters 返回客户端实际发送的生成参数，由链路追踪记录
+	// parameters returns the generation parameters the client actually sends, recorded by tracing
+	parameters func(input *Input, stream bool, opts []Option) requestParameters
 }
 
 // ProviderCapabilities 提供商能力
@@ -59,6 +68,8 @@ type ProviderCapabilities struct {
 	TopP          bool `json:"top_p"`          // 是否支持TopP参数 / Support top-p
 	Seed          bool `json:"seed"`           // 是否支持随机种子 / Support seed
 	SystemMessage bool `json:"system_message"` // 是否支持系统消息 / Support system message
+	Vision        bool `json:"vision"`         // 是否支持图像输入 / Support image input
+	JSONSchema    bool `json:"json_schema"`    // 是否支持按 JSON Schema 结构化输出 / Support structured output with a JSON schema
 }
 
 // ============================================================================
@@ -80,12 +91,13 @@ type Config struct {
 type ProviderType string
 
 const (
-	ProviderOpenAI  ProviderType = "openai"  // OpenAI
-	ProviderAzure   ProviderType = "azure"   // Azure OpenAI
-	ProviderClaude  ProviderType = "claude"  // Anthropic Claude
-	ProviderHunyuan ProviderType = "hunyuan" // 腾讯混元 / Tencent Hunyuan
-	ProviderGemini  ProviderType = "gemini"  // Google Gemini
-	ProviderCustom  ProviderType = "custom"  // 自定义提供商 / Custom provider
+	ProviderOpenAI          ProviderType = "openai"           // OpenAI
+	ProviderOpenAIResponses ProviderType = "openai_responses" // OpenAI Responses API
+	ProviderAzure           ProviderType = "azure"            // Azure OpenAI
+	ProviderClaude          ProviderType = "claude"           // Anthropic Claude
+	ProviderHunyuan         ProviderType = "hunyuan"          // 腾讯混元 / Tencent Hunyuan
+	ProviderGemini          ProviderType = "gemini"           // Google Gemini
+	ProviderCustom          ProviderType = "custom"           // 自定义提供商 / Custom provider
 )
 
 // ============================================================================
@@ -99,6 +111,12 @@ type LLMError struct {
 	Code     string       `json:"code"`     // 错误码 / Error code
 	Message  string       `json:"message"`  // 错误消息 / Error message
 	Cause    error        `json:"cause"`    // 原始错误 / Original error
+
+	// 以下字段仅在提供商 API 返回错误时设置 / The fields below are only set for provider API errors
+	StatusCode int           `json:"status_code,omitempty"` // HTTP状态码 / HTTP status code
+	RequestID  string        `json:"request_id,omitempty"`  // 提供商请求ID / Provider request ID
+	Type       string        `json:"type,omitempty"`        // 提供商返回的错误类型 / Error type reported by the provider
+	RetryAfter time.Duration `json:"retry_after,omitempty"` // 提供商建议的重试间隔 / Retry delay suggested by the provider
 }
 
 // Error 实现error接口
@@ -116,6 +134,44 @@ func (e *LLMError) Unwrap() error {
 	return e.Cause
 }
 
+// SchemaValidationError 结构化输出不符合 JSON Schema 的错误
+// SchemaValidationError reports a structured output that violates the JSON schema
+type SchemaValidationError struct {
+	Path    string `json:"path"`    // 出错的字段路径，如 $.items[0].name / Path of the offending field
+	Message string `json:"message"` // 错误描述 / Violation description
+	Content string `json:"content"` // 模型返回的原始内容 / Raw content returned by the model
+}
+
+// Error 实现error接口
+// Error implements the error interface
+func (e *SchemaValidationError) Error() string {
+	return "响应不符合JSON Schema: " + e.Path + ": " + e.Message
+}
+
+// IncompleteError 流式响应中断（取消、超时或连接断开）的错误
+// Output 为中断前已收到的部分输出，其 FinishReason 为 cancelled 或 error
+// IncompleteError reports a stream that was interrupted by cancellation, a timeout or a dropped connection
+// Output holds the partial output received before the interruption, with FinishReason cancelled or error
+type IncompleteError struct {
+	Output *Output `json:"output"` // 部分输出 / Partial output
+	Err    error   `json:"err"`    // 中断原因 / Cause of the interruption
+}
+
+// Error 实现error接口
+// Error implements the error interface
+func (e *IncompleteError) Error() string {
+	if e.Err != nil {
+		return "流式响应未完成: " + e.Err.Error()
+	}
+	return "流式响应未完成"
+}
+
+// Unwrap 实现errors.Unwrap接口
+// Unwrap implements the errors.Unwrap interface
+func (e *IncompleteError) Unwrap() error {
+	return e.Err
+}
+
 // ============================================================================
 // 构造错误的辅助函数 / Helper Functions for Error Construction
 // ============================================================================
@@ -151,12 +207,98 @@ const (
 type Message struct {
 	Role       MessageRole    `json:"role"`                   // 消息角色 / Message role
 	Content    string         `json:"content"`                // 文本内容 / Text content
+	Parts      []ContentPart  `json:"parts,omitempty"`        // 多模态内容片段（非空时优先于Content） / Multimodal content parts (take precedence over Content when set)
 	ToolCalls  []ToolCall     `json:"tool_calls,omitempty"`   // 工具调用列表（仅assistant） / Tool calls (assistant only)
 	ToolCallID string         `json:"tool_call_id,omitempty"` // 工具调用ID（仅tool） / Tool call ID (tool only)
+	IsError    bool           `json:"is_error,omitempty"`     // 工具执行是否失败（仅tool） / Whether the tool execution failed (tool only)
 	Name       string         `json:"name,omitempty"`         // 消息名称（可选） / Message name (optional)
 	Metadata   map[string]any `json:"metadata,omitempty"`     // 元数据（扩展用） / Metadata (for extension)
 }
 
+// Text 返回消息的文本内容：未设置 Parts 时为 Content，否则为所有文本片段的拼接
+// Text returns the message text: Content when Parts is empty, otherwise the concatenated text parts
+func (m Message) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	var text strings.Builder
+	for _, part := range m.Parts {
+		if part.Type == PartText {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+// ============================================================================
+// 多模态内容片段 / Multimodal Content Parts
+// ============================================================================
+
+// ContentPartType 内容片段类型
+// ContentPartType represents the type of a content part
+type ContentPartType string
+
+const (
+	PartText     ContentPartType = "text"      // 文本 / Text
+	PartImageURL ContentPartType = "image_url" // 图片URL / Image URL
+	PartImage    ContentPartType = "image"     // 内联图片数据 / Inline image bytes
+	PartAudio    ContentPartType = "audio"     // 内联音频数据 / Inline audio bytes
+	PartFile     ContentPartType = "file"      // 文件（如PDF），内联数据或URL / File (e.g. PDF), inline bytes or URL
+)
+
+// ContentPart 多模态内容片段，Message.Parts 中按顺序发送给模型
+// ContentPart is a multimodal content part, sent to the model in Message.Parts order
+type ContentPart struct {
+	Type     ContentPartType `json:"type"`                // 片段类型 / Part type
+	Text     string          `json:"text,omitempty"`      // 文本内容（仅text） / Text content (text only)
+	URL      string          `json:"url,omitempty"`       // 资源URL（image_url/file） / Resource URL (image_url/file)
+	Data     []byte          `json:"data,omitempty"`      // 内联数据（image/audio/file） / Inline bytes (image/audio/file)
+	MIMEType string          `json:"mime_type,omitempty"` // MIME类型，如image/png、audio/wav、application/pdf / MIME type
+	FileName string          `json:"file_name,omitempty"` // 文件名（仅file） / File name (file only)
+}
+
+// dataURL 将内联数据编码为 data URL
+// dataURL encodes the inline bytes as a data URL
+func (p ContentPart) dataURL() string {
+	return "data:" + p.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// TextPart 创建文本片段
+// TextPart creates a text content part
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImageURLPart 创建图片URL片段
+// ImageURLPart creates an image URL content part
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: PartImageURL, URL: url}
+}
+
+// ImagePart 创建内联图片片段
+// ImagePart creates an inline image content part
+func ImagePart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: PartImage, Data: data, MIMEType: mimeType}
+}
+
+// AudioPart 创建内联音频片段
+// AudioPart creates an inline audio content part
+func AudioPart(data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: PartAudio, Data: data, MIMEType: mimeType}
+}
+
+// FilePart 创建内联文件片段（如PDF）
+// FilePart creates an inline file content part (e.g. PDF)
+func FilePart(fileName string, data []byte, mimeType string) ContentPart {
+	return ContentPart{Type: PartFile, FileName: fileName, Data: data, MIMEType: mimeType}
+}
+
+// FileURLPart 创建文件URL片段
+// FileURLPart creates a file URL content part
+func FileURLPart(url string, mimeType string) ContentPart {
+	return ContentPart{Type: PartFile, URL: url, MIMEType: mimeType}
+}
+
 // ============================================================================
 // 统一工具调用结构 / Union Tool Call Structure
 // ============================================================================
@@ -164,9 +306,10 @@ type Message struct {
 // ToolCall 统一工具调用格式
 // ToolCall represents a unified tool call format
 type ToolCall struct {
-	ID        string         `json:"id"`        // 工具调用ID / Tool call ID
-	Name      string         `json:"name"`      // 工具名称 / Tool name
-	Arguments map[string]any `json:"arguments"` // 工具参数 / Tool arguments
+	ID        string         `json:"id"`                 // 工具调用ID / Tool call ID
+	Name      string         `json:"name"`               // 工具名称 / Tool name
+	Arguments map[string]any `json:"arguments"`          // 工具参数 / Tool arguments
+	Metadata  map[string]any `json:"metadata,omitempty"` // 提供商回传数据（如 Gemini thought signature） / Provider round-trip data (e.g. Gemini thought signature)
 }
 
 // Tool 工具定义
@@ -200,6 +343,9 @@ type JSONSchema struct {
 	Description string                 `json:"description,omitempty"` // 字段描述 / Field description
 	Minimum     *float64               `json:"minimum,omitempty"`     // 最小值（number/integer）/ Minimum value (number/integer)
 	Maximum     *float64               `json:"maximum,omitempty"`     // 最大值（number/integer）/ Maximum value (number/integer)
+	Format      string                 `json:"format,omitempty"`      // 字符串格式，如 date-time / String format, such as date-time
+
+	AdditionalProperties *JSONSchema `json:"additionalProperties,omitempty"` // 任意键的值定义（map类型）/ Schema of values for arbitrary keys (map type)
 }
 
 // ============================================================================
@@ -261,6 +407,17 @@ type Input struct {
 	ToolChoice *ToolChoiceOption `json:"tool_choice,omitempty"` // 工具选择策略 / Tool choice strategy
 	Stream     bool              `json:"stream"`                // 是否流式 / Whether streaming
 
+	// ResponseFormat 结构化输出格式，设置后模型按 JSON Schema 返回内容
+	// ResponseFormat requests JSON output conforming to the schema
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat 结构化输出格式定义
+// ResponseFormat defines the structured output format
+type ResponseFormat struct {
+	Name        string      `json:"name"`                  // 格式名称，仅允许字母、数字、下划线和中划线 / Format name
+	Description string      `json:"description,omitempty"` // 格式描述 / Format description
+	Schema      *JSONSchema `json:"schema"`                // 输出内容的 JSON Schema / JSON schema of the output
 }
 
 // ============================================================================
@@ -277,6 +434,7 @@ const (
 	FinishReasonToolCalls     FinishReason = "tool_calls"     // 需要调用工具 / Tool calls required
 	FinishReasonContentFilter FinishReason = "content_filter" // 内容过滤 / Content filtered
 	FinishReasonError         FinishReason = "error"          // 错误 / Error
+	FinishReasonCancelled     FinishReason = "cancelled"      // 请求被取消或超时 / Request cancelled or timed out
 )
 
 // FromFinishReason 将结束原因转换为Union格式
@@ -300,11 +458,24 @@ func FromFinishReason(reason string) FinishReason {
 // TokenUsage represents token consumption statistics
 type TokenUsage struct {
 	InputTokens    int64 `json:"input_tokens"`    // 输入token数 / Input tokens
+	CachedTokens   int64 `json:"cached_tokens"`   // 命中缓存的输入token数（包含在InputTokens中）/ Cached input tokens (included in InputTokens)
 	ThinkingTokens int64 `json:"thinking_tokens"` // 思考token数（仅支持思考模型） / Thinking tokens (only for thinking models)
 	OutputTokens   int64 `json:"output_tokens"`   // 输出token数 / Output tokens
 	TotalTokens    int64 `json:"total_tokens"`    // 总token数 / Total tokens
 }
 
+// Add 返回两次调用 token 使用量之和
+// Add returns the sum of the token usage of two calls
+func (u TokenUsage) Add(other TokenUsage) TokenUsage {
+	return TokenUsage{
+		InputTokens:    u.InputTokens + other.InputTokens,
+		CachedTokens:   u.CachedTokens + other.CachedTokens,
+		ThinkingTokens: u.ThinkingTokens + other.ThinkingTokens,
+		OutputTokens:   u.OutputTokens + other.OutputTokens,
+		TotalTokens:    u.TotalTokens + other.TotalTokens,
+	}
+}
+
 // Output 统一LLM响应格式 - 适配所有LLM提供商
 // Output represents a unified LLM response format across all providers
 type Output struct {
@@ -316,6 +487,8 @@ type Output struct {
 	TokenUsage   TokenUsage    `json:"token_usage"`   // Token使用情况 / Token usage
 	Cost         time.Duration `json:"cost"`          // 调用耗时 / Call duration
 	RawResponse  any           `json:"raw_response"`  // 原始响应（调试用） / Raw response (for debugging)
+	// 首个内容增量的延迟，仅流式调用记录 / Latency of the first content delta, recorded for streaming calls only
+	TimeToFirstToken time.Duration `json:"time_to_first_token,omitempty"`
 	// 扩展字段（提供商特定数据）/ Extended fields (provider-specific data)
 	Extra map[string]any `json:"extra,omitempty"`
 }
@@ -342,6 +515,29 @@ func UserMessage(content string) Message {
 	}
 }
 
+// UserMessageWithParts 创建多模态用户消息
+// UserMessageWithParts creates a multimodal user message
+func UserMessageWithParts(parts ...ContentPart) Message {
+	msg := Message{
+		Role:  RoleUser,
+		Parts: parts,
+	}
+	msg.Content = msg.Text()
+	return msg
+}
+
+// UserMessageWithImage 创建包含图片URL的用户消息
+// UserMessageWithImage creates a user message with an image URL
+func UserMessageWithImage(content string, imageURL string) Message {
+	return UserMessageWithParts(TextPart(content), ImageURLPart(imageURL))
+}
+
+// UserMessageWithImageData 创建包含内联图片的用户消息
+// UserMessageWithImageData creates a user message with inline image bytes
+func UserMessageWithImageData(content string, data []byte, mimeType string) Message {
+	return UserMessageWithParts(TextPart(content), ImagePart(data, mimeType))
+}
+
 // AssistantMessage 创建助手消息（纯文本）
 // AssistantMessage creates an assistant message (text only)
 func AssistantMessage(content string) Message {
@@ -370,3 +566,14 @@ func ToolMessage(content string, toolCallID string) Message {
 		ToolCallID: toolCallID,
 	}
 }
+
+// ToolErrorMessage 创建工具执行失败的结果消息，content 为返回给模型的错误信息
+// ToolErrorMessage creates the result message of a failed tool execution; content is the error shown to the model
+func ToolErrorMessage(content string, toolCallID string) Message {
+	return Message{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallID: toolCallID,
+		IsError:    true,
+	}
+}
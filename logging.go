@@ -0,0 +1,212 @@
+package OpenLLM
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-io/requests"
+)
+
+// ============================================================================
+// 日志与脱敏 / Logging and Redaction
+// ============================================================================
+
+// redactedText 替换敏感信息的文本
+// redactedText replaces sensitive values
+const redactedText = "[REDACTED]"
+
+// maxLoggedBody 日志中请求和响应体的最大长度
+// maxLoggedBody is the maximum length of logged request and response bodies
+const maxLoggedBody = 8 << 10
+
+// sensitiveHeaders 总是脱敏的认证相关请求头和响应头
+// sensitiveHeaders are authentication related headers that are always redacted
+var sensitiveHeaders = []string{
+	"Authorization", "Proxy-Authorization", "Api-Key", "X-Api-Key", "X-Goog-Api-Key", "Cookie", "Set-Cookie",
+}
+
+// secretPatterns 总是脱敏的密钥格式：OpenAI/Anthropic/DeepSeek 等 sk- 密钥、Google API 密钥、Bearer 令牌和 URL 中的 key 参数
+// secretPatterns are key formats that are always redacted: sk- keys (OpenAI, Anthropic, DeepSeek and others),
+// Google API keys, bearer tokens and key parameters in URLs
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`), redactedText},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`), redactedText},
+	{regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/\-]+=*`), "Bearer " + redactedText},
+	{regexp.MustCompile(`(?i)([?&](?:key|api_key|api-key)=)[^&\s"]+`), "${1}" + redactedText},
+}
+
+// Redactor 日志脱敏函数，返回替换敏感信息后的文本
+// Redactor redacts sensitive information from text written to the log
+type Redactor func(text string) string
+
+// RedactPattern 创建将匹配 pattern 的文本替换为 replacement 的脱敏函数，replacement 支持 $1 等引用
+// RedactPattern creates a redactor replacing text matching pattern with replacement, which may use $1-style references
+func RedactPattern(pattern *regexp.Regexp, replacement string) Redactor {
+	return func(text string) string {
+		return pattern.ReplaceAllString(text, replacement)
+	}
+}
+
+// 常用的个人信息脱敏函数
+// Redactors for common personal information
+var (
+	// RedactEmails 替换电子邮箱地址 / RedactEmails replaces email addresses
+	RedactEmails = RedactPattern(regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "[EMAIL]")
+
+	// RedactPhoneNumbers 替换中国大陆手机号和带国际区号的电话号码
+	// RedactPhoneNumbers replaces mainland China mobile numbers and phone numbers with an international prefix
+	RedactPhoneNumbers = RedactPattern(regexp.MustCompile(`(?:\+86[- ]?)?\b1[3-9]\d{9}\b|\+\d{1,3}(?:[- ]?\d{2,4}){2,4}\b`), "[PHONE]")
+
+	// RedactIDNumbers 替换中国居民身份证号和美国社会安全号
+	// RedactIDNumbers replaces Chinese resident ID numbers and US social security numbers
+	RedactIDNumbers = RedactPattern(regexp.MustCompile(`\b\d{17}[\dXx]\b|\b\d{3}-\d{2}-\d{4}\b`), "[ID_NUMBER]")
+)
+
+// logger 返回配置的日志记录器，未配置时返回 slog.Default()
+// logger returns the configured logger, or slog.Default() when none is set
+func (o *Options) logger() *slog.Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return slog.Default()
+}
+
+// redact 替换文本中的 API 密钥、常见密钥格式，并依次应用配置的脱敏函数
+// redact removes the API key and common secret formats from the text, then applies the configured redactors in order
+func (o *Options) redact(text string) string {
+	if o.APIKey != "" {
+		text = strings.ReplaceAll(text, o.APIKey, redactedText)
+	}
+	for _, secret := range secretPatterns {
+		text = secret.pattern.ReplaceAllString(text, secret.replacement)
+	}
+	for _, redactor := range o.Redactors {
+		text = redactor(text)
+	}
+	return text
+}
+
+// httpClientOptions 返回创建 HTTP 客户端的配置，设置了 Logger 时追加请求日志中间件
+// 日志中间件最后注册、位于最内层，记录的是其他中间件处理之后实际发送的请求
+// httpClientOptions returns the options creating the HTTP client, adding the request logging middleware when
+// a Logger is set
+// The logging middleware is registered last and is therefore innermost, logging requests as actually sent
+func (o *Options) httpClientOptions() []requests.Option {
+	if o.Logger == nil {
+		return o.HTTPClientOptions
+	}
+	return append(slices.Clone(o.HTTPClientOptions), requests.Setup(o.logRoundTripper))
+}
+
+// logRoundTripper 记录请求和响应摘要的 HTTP 中间件，失败的请求至少以 Warn 级别记录
+// 开启 LogContent 时同时记录请求头、请求体和非流式响应体；认证请求头和密钥总是被脱敏
+// logRoundTripper is an HTTP middleware logging request and response summaries; failed requests are logged at Warn
+// level or above
+// With LogContent, headers, request bodies and non-streaming response bodies are logged as well;
+// authentication headers and secrets are always redacted
+func (o *Options) logRoundTripper(next http.RoundTripper) http.RoundTripper {
+	return requests.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		ctx, logger := req.Context(), o.logger()
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("url", o.redact(req.URL.String())),
+		}
+		if o.LogContent {
+			body, err := readBody(&req.Body)
+			if err != nil {
+				return nil, err
+			}
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+			attrs = append(attrs, o.headersAttr("headers", req.Header), slog.String("body", o.redactBody(body)))
+		}
+		logger.LogAttrs(ctx, o.LogLevel, "LLM请求", attrs...)
+
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		attrs = append(attrs[:2], slog.Duration("duration", time.Since(start)))
+		if err != nil {
+			logger.LogAttrs(ctx, max(o.LogLevel, slog.LevelWarn), "LLM请求失败", append(attrs, slog.String("error", o.redact(err.Error())))...)
+			return resp, err
+		}
+
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := requestID(resp.Header); id != "" {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+		if o.LogContent && !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+			body, err := readBody(&resp.Body)
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, o.headersAttr("headers", resp.Header), slog.String("body", o.redactBody(body)))
+		}
+		level := o.LogLevel
+		if resp.StatusCode >= http.StatusBadRequest {
+			level = max(level, slog.LevelWarn)
+		}
+		logger.LogAttrs(ctx, level, "LLM响应", attrs...)
+		return resp, nil
+	})
+}
+
+// headersAttr 将请求头或响应头转换为日志属性，认证相关的头部被替换
+// headersAttr converts headers to a log attribute, replacing authentication related headers
+func (o *Options) headersAttr(key string, header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		value := strings.Join(header.Values(name), ", ")
+		if slices.ContainsFunc(sensitiveHeaders, func(s string) bool { return strings.EqualFold(s, name) }) {
+			value = redactedText
+		}
+		attrs = append(attrs, slog.String(name, o.redact(value)))
+	}
+	return slog.Group(key, attrs...)
+}
+
+// redactBody 脱敏并截断请求体或响应体
+// redactBody redacts and truncates a request or response body
+func (o *Options) redactBody(body []byte) string {
+	text := o.redact(string(body))
+	if len(text) > maxLoggedBody {
+		return text[:maxLoggedBody] + "...(truncated)"
+	}
+	return text
+}
+
+// readBody 读取并替换 body，使其仍可被后续读取
+// readBody reads the body and replaces it so it can still be read afterwards
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// requestID 从响应头读取提供商的请求ID
+// requestID reads the provider's request ID from the response headers
+func requestID(header http.Header) string {
+	for _, name := range []string{"X-Request-Id", "Request-Id", "X-Goog-Request-Id"} {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package OpenLLM
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	const apiKey = "sk-test-0123456789abcdefghij"
+	server := newErrorServer(t, http.StatusOK, map[string]string{"x-request-id": "req_123"}, `{
+		"id": "1", "object": "chat.completion", "created": 1, "model": "m",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "已发送到 alice@example.com"}}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+	}`)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := CreateOpenAI(URL(server.URL), APIKey(apiKey), Logger(logger), LogContent(), Redact(RedactEmails, RedactPhoneNumbers))
+
+	input := &Input{Model: "m", Messages: []Message{UserMessage("请联系 13812345678 或 alice@example.com")}}
+	output, err := client.Completion(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	if !strings.Contains(output.Content, "alice@example.com") {
+		t.Errorf("Expected the response to be unaffected by redaction, got %q", output.Content)
+	}
+
+	logs := buf.String()
+	for _, want := range []string{"LLM请求", "LLM响应", "status=200", "request_id=req_123", "headers.Authorization=[REDACTED]", "[EMAIL]", "[PHONE]"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Expected logs to contain %q, got:\n%s", want, logs)
+		}
+	}
+	for _, secret := range []string{apiKey, "alice@example.com", "13812345678"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("Expected %q to be redacted, got:\n%s", secret, logs)
+		}
+	}
+}
+
+func TestLogger_LevelAndFailures(t *testing.T) {
+	server := newErrorServer(t, http.StatusTooManyRequests, nil, `{"error": {"message": "rate limited", "type": "requests"}}`)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	client := CreateOpenAI(URL(server.URL), APIKey("test"), Logger(logger))
+
+	if _, err := client.Completion(context.Background(), &Input{Model: "m", Messages: []Message{UserMessage("hi")}}); err == nil {
+		t.Fatal("Expected an error")
+	}
+	logs := buf.String()
+	if strings.Contains(logs, "LLM请求") {
+		t.Errorf("Expected debug request summaries to be filtered at info level, got:\n%s", logs)
+	}
+	if !strings.Contains(logs, "level=WARN") || !strings.Contains(logs, "status=429") {
+		t.Errorf("Expected the failed response to be logged as a warning, got:\n%s", logs)
+	}
+	if strings.Contains(logs, "body=") || strings.Contains(logs, "headers.") {
+		t.Errorf("Expected bodies and headers not to be logged without LogContent, got:\n%s", logs)
+	}
+}
+
+func TestRedactors(t *testing.T) {
+	options := newOptions(nil, APIKey("my-custom-key"), Redact(RedactEmails, RedactPhoneNumbers, RedactIDNumbers))
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"配置的API密钥", "key is my-custom-key", "key is [REDACTED]"},
+		{"sk密钥", "token sk-proj-abcdefghijklmnop1234", "token [REDACTED]"},
+		{"Bearer令牌", "Authorization: Bearer abc.def-ghi", "Authorization: Bearer [REDACTED]"},
+		{"URL中的key参数", "https://example.com/v1?alt=sse&key=AIzaSecret", "https://example.com/v1?alt=sse&key=[REDACTED]"},
+		{"邮箱", "联系bob.smith+ai@mail.example.cn谢谢", "联系[EMAIL]谢谢"},
+		{"手机号", "电话+86 13912345678。", "电话[PHONE]。"},
+		{"国际电话", "call +1 415 555 0100 now", "call [PHONE] now"},
+		{"身份证号", "身份证11010519491231002X", "身份证[ID_NUMBER]"},
+		{"社会安全号", "SSN 123-45-6789", "SSN [ID_NUMBER]"},
+		{"普通文本", "订单 12345 已发货", "订单 12345 已发货"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := options.redact(tt.in); got != tt.want {
+				t.Errorf("redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
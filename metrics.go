@@ -0,0 +1,195 @@
+package OpenLLM
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.36.0"
+)
+
+// ============================================================================
+// 指标 / Metrics
+// ============================================================================
+
+// RequestMetrics 一次模型调用的指标
+// RequestMetrics holds the metrics of a single model call
+type RequestMetrics struct {
+	Provider          ProviderType  `json:"provider"`                      // 提供商类型 / Provider type
+	Model             string        `json:"model"`                         // 模型名称 / Model name
+	Stream            bool          `json:"stream"`                        // 是否流式调用 / Whether the call is streaming
+	Duration          time.Duration `json:"duration"`                      // 端到端耗时 / End-to-end latency
+	TimeToFirstToken  time.Duration `json:"time_to_first_token,omitempty"` // 首个内容增量的延迟（仅流式）/ Time to the first content delta (streaming only)
+	InterTokenLatency time.Duration `json:"inter_token_latency,omitempty"` // 相邻内容增量的平均间隔（仅流式）/ Mean gap between content deltas (streaming only)
+	TokenUsage        TokenUsage    `json:"token_usage"`                   // Token使用情况，出错时为部分输出的用量 / Token usage, from the partial output on errors
+	ErrorCode         string        `json:"error_code,omitempty"`          // 错误码，成功时为空 / Error code, empty on success
+}
+
+// MetricsRecorder 接收每次模型调用的指标，实现需要支持并发调用
+// MetricsRecorder receives the metrics of every model call; implementations must be safe for concurrent use
+type MetricsRecorder interface {
+	RecordRequest(ctx context.Context, metrics RequestMetrics)
+}
+
+// Metrics 返回指标中间件，每次 Completion 和 CompletionStream 调用结束后向 recorder 报告一次指标
+// Metrics returns a metrics middleware reporting to recorder once every Completion and CompletionStream call ends
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next LLM) LLM {
+		info := providerInfoOf(next)
+		return Interceptor{
+			Completion: func(ctx context.Context, input *Input, call CompletionFunc, opts ...Option) (*Output, error) {
+				start := time.Now()
+				output, err := call(ctx, input, opts...)
+				metrics := newRequestMetrics(info, input, opts, output, err)
+				metrics.Duration = time.Since(start)
+				recorder.RecordRequest(ctx, metrics)
+				return output, err
+			},
+			Stream: func(ctx context.Context, input *Input, handler StreamHandler, call StreamFunc, opts ...Option) (*Output, error) {
+				timer := tokenTimer{start: time.Now()}
+				output, err := call(ctx, input, func(event StreamEvent) {
+					timer.observe(event)
+					handler.send(event)
+				}, opts...)
+				metrics := newRequestMetrics(info, input, opts, output, err)
+				metrics.Stream = true
+				metrics.Duration = time.Since(timer.start)
+				metrics.TimeToFirstToken = timer.timeToFirstToken()
+				metrics.InterTokenLatency = timer.interTokenLatency()
+				recorder.RecordRequest(ctx, metrics)
+				return output, err
+			},
+		}.Middleware()(next)
+	}
+}
+
+// newRequestMetrics 根据调用结果创建指标，耗时由调用方填写
+// newRequestMetrics creates the metrics from the call result; the caller fills in the latencies
+func newRequestMetrics(info ProviderInfo, input *Input, opts []Option, output *Output, err error) RequestMetrics {
+	model, _ := requestModel(info, input, opts)
+	metrics := RequestMetrics{Provider: info.Type, Model: model}
+	if output != nil {
+		metrics.TokenUsage = output.TokenUsage
+	}
+	if err != nil {
+		metrics.ErrorCode = errorType(err)
+	}
+	return metrics
+}
+
+// ============================================================================
+// OpenTelemetry 指标 / OpenTelemetry Metrics
+// ============================================================================
+
+// GenAI 语义约定建议的直方图桶边界
+// Histogram bucket boundaries advised by the GenAI semantic conventions
+var (
+	durationBuckets   = []float64{0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92}
+	ttftBuckets       = []float64{0.001, 0.005, 0.01, 0.02, 0.04, 0.06, 0.08, 0.1, 0.25, 0.5, 0.75, 1.0, 2.5, 5.0, 7.5, 10.0}
+	interTokenBuckets = []float64{0.01, 0.025, 0.05, 0.075, 0.1, 0.15, 0.2, 0.3, 0.4, 0.5, 0.75, 1.0, 2.5}
+	tokenBuckets      = []float64{1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864}
+)
+
+// genAITokenTypeThinking 思考 token 的 gen_ai.token.type 值，语义约定未定义
+// genAITokenTypeThinking is the gen_ai.token.type value of thinking tokens, not defined by the semantic conventions
+var genAITokenTypeThinking = semconv.GenAITokenTypeKey.String("thinking")
+
+var _ MetricsRecorder = (*OTelMetrics)(nil)
+
+// OTelMetrics 将调用指标写入 OpenTelemetry 的 MetricsRecorder 实现
+// 指标按 gen_ai.system 和 gen_ai.request.model 区分，错误按 error.type（LLMError.Code）区分
+// OTelMetrics is a MetricsRecorder writing call metrics to OpenTelemetry
+// Metrics are attributed by gen_ai.system and gen_ai.request.model, and errors by error.type (LLMError.Code)
+type OTelMetrics struct {
+	requests          metric.Int64Counter
+	errors            metric.Int64Counter
+	duration          metric.Float64Histogram
+	timeToFirstToken  metric.Float64Histogram
+	interTokenLatency metric.Float64Histogram
+	tokens            metric.Int64Histogram
+}
+
+// NewOTelMetrics 使用 provider 创建指标，provider 为 nil 时使用 otel.GetMeterProvider()
+// NewOTelMetrics creates the instruments with provider, using otel.GetMeterProvider() when provider is nil
+func NewOTelMetrics(provider metric.MeterProvider) (*OTelMetrics, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	meter := provider.Meter(tracerName)
+
+	var m OTelMetrics
+	var err error
+	if m.requests, err = meter.Int64Counter("gen_ai.client.requests",
+		metric.WithDescription("Number of GenAI requests"), metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if m.errors, err = meter.Int64Counter("gen_ai.client.errors",
+		metric.WithDescription("Number of failed GenAI requests"), metric.WithUnit("{error}")); err != nil {
+		return nil, err
+	}
+	if m.duration, err = meter.Float64Histogram("gen_ai.client.operation.duration",
+		metric.WithDescription("GenAI operation duration"), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...)); err != nil {
+		return nil, err
+	}
+	if m.timeToFirstToken, err = meter.Float64Histogram("gen_ai.client.time_to_first_token",
+		metric.WithDescription("Time to the first content delta of streaming requests"), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(ttftBuckets...)); err != nil {
+		return nil, err
+	}
+	if m.interTokenLatency, err = meter.Float64Histogram("gen_ai.client.inter_token_latency",
+		metric.WithDescription("Mean gap between content deltas of streaming requests"), metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(interTokenBuckets...)); err != nil {
+		return nil, err
+	}
+	if m.tokens, err = meter.Int64Histogram("gen_ai.client.token.usage",
+		metric.WithDescription("Number of input, output and thinking tokens used"), metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(tokenBuckets...)); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// RecordRequest 记录一次调用的指标
+// RecordRequest records the metrics of a single call
+func (m *OTelMetrics) RecordRequest(ctx context.Context, metrics RequestMetrics) {
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		genAISystem(metrics.Provider),
+		semconv.GenAIRequestModel(metrics.Model),
+	}
+	common := metric.WithAttributes(attrs...)
+
+	m.requests.Add(ctx, 1, common)
+	if metrics.ErrorCode != "" {
+		withError := metric.WithAttributes(append(attrs, semconv.ErrorTypeKey.String(metrics.ErrorCode))...)
+		m.errors.Add(ctx, 1, withError)
+		m.duration.Record(ctx, metrics.Duration.Seconds(), withError)
+	} else {
+		m.duration.Record(ctx, metrics.Duration.Seconds(), common)
+	}
+	if metrics.TimeToFirstToken > 0 {
+		m.timeToFirstToken.Record(ctx, metrics.TimeToFirstToken.Seconds(), common)
+	}
+	if metrics.InterTokenLatency > 0 {
+		m.interTokenLatency.Record(ctx, metrics.InterTokenLatency.Seconds(), common)
+	}
+
+	// 未返回用量（如关闭了流式用量）时不记录，避免 0 值拉低分布
+	// Missing usage (for example with stream usage disabled) is skipped so zeros do not skew the distribution
+	usage := metrics.TokenUsage
+	for _, tokens := range []struct {
+		count int64
+		kind  attribute.KeyValue
+	}{
+		{usage.InputTokens, semconv.GenAITokenTypeInput},
+		{usage.OutputTokens, semconv.GenAITokenTypeOutput},
+		{usage.ThinkingTokens, genAITokenTypeThinking},
+	} {
+		if tokens.count > 0 {
+			m.tokens.Record(ctx, tokens.count, metric.WithAttributes(append(attrs, tokens.kind)...))
+		}
+	}
+}
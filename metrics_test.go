@@ -0,0 +1,114 @@
+package OpenLLM
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// metricsCollector 收集调用指标的 MetricsRecorder
+// metricsCollector is a MetricsRecorder collecting the call metrics
+type metricsCollector struct {
+	mu      sync.Mutex
+	metrics []RequestMetrics
+}
+
+func (c *metricsCollector) RecordRequest(ctx context.Context, metrics RequestMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metrics = append(c.metrics, metrics)
+}
+
+func TestMetrics(t *testing.T) {
+	collector := &metricsCollector{}
+	llm := &mockLLM{provider: ProviderGemini, responses: []mockResponse{
+		{output: &Output{Content: "你好", TokenUsage: TokenUsage{InputTokens: 8, OutputTokens: 2, ThinkingTokens: 4}}},
+		{err: NewLLMError(ProviderGemini, ErrCodeContextLengthExceeded, "超出上下文长度", nil)},
+	}}
+	measured := Chain(llm, Metrics(collector))
+
+	if _, err := measured.CompletionStream(context.Background(), &Input{Model: "gemini-test"}, func(string) {}); err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	if _, err := measured.Completion(context.Background(), &Input{}, Model("gemini-option")); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if len(collector.metrics) != 2 {
+		t.Fatalf("Expected 2 recorded calls, got %d", len(collector.metrics))
+	}
+	stream, failed := collector.metrics[0], collector.metrics[1]
+	if stream.Provider != ProviderGemini || stream.Model != "gemini-test" || !stream.Stream {
+		t.Errorf("Unexpected stream metrics: %+v", stream)
+	}
+	if stream.TimeToFirstToken <= 0 || stream.TimeToFirstToken > stream.Duration {
+		t.Errorf("Expected time to first token within the duration, got %v (duration %v)", stream.TimeToFirstToken, stream.Duration)
+	}
+	if stream.TokenUsage.ThinkingTokens != 4 || stream.ErrorCode != "" {
+		t.Errorf("Unexpected stream usage or error: %+v", stream)
+	}
+	if failed.Model != "gemini-option" || failed.Stream || failed.ErrorCode != ErrCodeContextLengthExceeded {
+		t.Errorf("Unexpected failed call metrics: %+v", failed)
+	}
+}
+
+func TestOTelMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	recorder, err := NewOTelMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	if err != nil {
+		t.Fatalf("NewOTelMetrics() error = %v", err)
+	}
+
+	ctx := context.Background()
+	recorder.RecordRequest(ctx, RequestMetrics{
+		Provider: ProviderOpenAI, Model: "gpt-test", Stream: true,
+		Duration: 2e9, TimeToFirstToken: 3e8, InterTokenLatency: 2e7,
+		TokenUsage: TokenUsage{InputTokens: 100, OutputTokens: 20},
+	})
+	recorder.RecordRequest(ctx, RequestMetrics{Provider: ProviderOpenAI, Model: "gpt-test", Duration: 1e9, ErrorCode: ErrCodeRateLimited})
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &data); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	requests := metrics["gen_ai.client.requests"].(metricdata.Sum[int64])
+	if len(requests.DataPoints) != 1 || requests.DataPoints[0].Value != 2 {
+		t.Errorf("Expected 2 requests, got %+v", requests.DataPoints)
+	}
+	system, _ := requests.DataPoints[0].Attributes.Value("gen_ai.system")
+	model, _ := requests.DataPoints[0].Attributes.Value("gen_ai.request.model")
+	if system.AsString() != "openai" || model.AsString() != "gpt-test" {
+		t.Errorf("Unexpected request attributes: %v", requests.DataPoints[0].Attributes)
+	}
+
+	errs := metrics["gen_ai.client.errors"].(metricdata.Sum[int64])
+	if errType, _ := errs.DataPoints[0].Attributes.Value("error.type"); errs.DataPoints[0].Value != 1 || errType.AsString() != ErrCodeRateLimited {
+		t.Errorf("Unexpected error data points: %+v", errs.DataPoints)
+	}
+
+	ttft := metrics["gen_ai.client.time_to_first_token"].(metricdata.Histogram[float64])
+	if ttft.DataPoints[0].Count != 1 || ttft.DataPoints[0].Sum != 0.3 {
+		t.Errorf("Unexpected time to first token: %+v", ttft.DataPoints)
+	}
+
+	tokens := metrics["gen_ai.client.token.usage"].(metricdata.Histogram[int64])
+	sums := make(map[string]int64)
+	for _, point := range tokens.DataPoints {
+		kind, _ := point.Attributes.Value(attribute.Key("gen_ai.token.type"))
+		sums[kind.AsString()] = point.Sum
+	}
+	if len(sums) != 2 || sums["input"] != 100 || sums["output"] != 20 {
+		t.Errorf("Expected input and output token usage only, got %v", sums)
+	}
+}
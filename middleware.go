@@ -0,0 +1,105 @@
+package OpenLLM
+
+import "context"
+
+// ============================================================================
+// 中间件 / Middleware
+// ============================================================================
+
+// Middleware LLM 中间件，包装 LLM 以增加日志、指标、脱敏、缓存、重试等横切逻辑
+// 返回的 LLM 应同时实现 EventStreamer，否则下游的类型化流式事件会退化为纯文本
+// Middleware wraps an LLM to add cross-cutting behaviour such as logging, metrics, redaction, caching or retries
+// The returned LLM should also implement EventStreamer, otherwise typed streaming events degrade to plain text
+type Middleware func(LLM) LLM
+
+// Chain 按顺序应用中间件，第一个中间件位于最外层，最先看到调用
+// Chain applies the middlewares in order; the first middleware is the outermost and sees each call first
+func Chain(llm LLM, mws ...Middleware) LLM {
+	for i := len(mws) - 1; i >= 0; i-- {
+		llm = mws[i](llm)
+	}
+	return llm
+}
+
+// CompletionFunc 非流式调用函数
+// CompletionFunc performs a non-streaming call
+type CompletionFunc func(ctx context.Context, input *Input, opts ...Option) (*Output, error)
+
+// StreamFunc 流式调用函数
+// StreamFunc performs a streaming call
+type StreamFunc func(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error)
+
+// Interceptor 以函数方式定义中间件，未设置的拦截函数直接调用下一层
+// 可以修改 Input 和 opts 后调用 next，也可以替换 handler 以观察或改写流式事件
+// Interceptor defines a middleware with functions; unset interceptors call the next layer directly
+// They may modify the input and opts before calling next, or replace the handler to observe or transform stream events
+type Interceptor struct {
+	// Completion 拦截 Completion 调用
+	// Completion intercepts Completion calls
+	Completion func(ctx context.Context, input *Input, next CompletionFunc, opts ...Option) (*Output, error)
+
+	// Stream 拦截 CompletionStream 和 CompletionStreamEvents 调用，字符串回调会被适配为 StreamHandler
+	// Stream intercepts CompletionStream and CompletionStreamEvents calls; string callbacks are adapted to a StreamHandler
+	Stream func(ctx context.Context, input *Input, handler StreamHandler, next StreamFunc, opts ...Option) (*Output, error)
+}
+
+// Middleware 将拦截函数转换为中间件
+// Middleware converts the interceptor into a middleware
+func (i Interceptor) Middleware() Middleware {
+	return func(next LLM) LLM {
+		return &interceptedLLM{next: next, interceptor: i}
+	}
+}
+
+// WithRetry 返回为 LLM 增加重试的中间件，等价于 NewRetry
+// WithRetry returns a middleware adding retries to the LLM, equivalent to NewRetry
+func WithRetry(opts ...RetryOption) Middleware {
+	return func(llm LLM) LLM {
+		return NewRetry(llm, opts...)
+	}
+}
+
+var (
+	_ LLM           = (*interceptedLLM)(nil)
+	_ EventStreamer = (*interceptedLLM)(nil)
+)
+
+// interceptedLLM 经过拦截函数调用下一层的 LLM
+// interceptedLLM is an LLM calling the next layer through the interceptor
+type interceptedLLM struct {
+	next        LLM
+	interceptor Interceptor
+}
+
+// Completion 执行单次对话完成（非流式）
+// Completion performs a single conversation completion (non-streaming)
+func (l *interceptedLLM) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	if l.interceptor.Completion == nil {
+		return l.next.Completion(ctx, input, opts...)
+	}
+	return l.interceptor.Completion(ctx, input, l.next.Completion, opts...)
+}
+
+// CompletionStream 执行单次对话完成（流式）
+// CompletionStream performs a single conversation completion (streaming)
+func (l *interceptedLLM) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return l.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+func (l *interceptedLLM) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	next := func(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+		return CompletionStreamEvents(ctx, l.next, input, handler, opts...)
+	}
+	if l.interceptor.Stream == nil {
+		return next(ctx, input, handler, opts...)
+	}
+	return l.interceptor.Stream(ctx, input, handler, next, opts...)
+}
+
+// Provider 返回下一层 LLM 的提供商信息
+// Provider returns the provider information of the next LLM
+func (l *interceptedLLM) Provider() ProviderInfo {
+	return providerInfoOf(l.next)
+}
@@ -0,0 +1,77 @@
+package OpenLLM
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return Interceptor{
+			Completion: func(ctx context.Context, input *Input, next CompletionFunc, opts ...Option) (*Output, error) {
+				calls = append(calls, name)
+				return next(ctx, input, opts...)
+			},
+		}.Middleware()
+	}
+
+	llm := &mockLLM{provider: ProviderOpenAI, responses: []mockResponse{
+		{err: NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)},
+		{output: &Output{Content: "你好"}},
+	}}
+	chained := Chain(llm, trace("outer"), trace("inner"), WithRetry(Backoff(time.Millisecond, time.Millisecond)))
+
+	output, err := chained.Completion(context.Background(), &Input{})
+	if err != nil || output.Content != "你好" {
+		t.Fatalf("Completion() = %v, %v", output, err)
+	}
+	if strings.Join(calls, ",") != "outer,inner" {
+		t.Errorf("Expected outer middleware to run first, got %v", calls)
+	}
+	if providerOf(chained) != ProviderOpenAI {
+		t.Errorf("Expected the provider of the wrapped LLM, got %s", providerOf(chained))
+	}
+}
+
+func TestInterceptor_Stream(t *testing.T) {
+	// 将流式文本转换为大写，并在调用前修改 Input 和追加 Option
+	upper := Interceptor{
+		Stream: func(ctx context.Context, input *Input, handler StreamHandler, next StreamFunc, opts ...Option) (*Output, error) {
+			request := *input
+			request.Model = "mapped"
+			return next(ctx, &request, func(event StreamEvent) {
+				event.Content = strings.ToUpper(event.Content)
+				handler.send(event)
+			}, append(opts, Temperature(0.9))...)
+		},
+	}.Middleware()
+
+	var options *Options
+	inner := Interceptor{
+		Stream: func(ctx context.Context, input *Input, handler StreamHandler, next StreamFunc, opts ...Option) (*Output, error) {
+			options = newOptions(nil, opts...)
+			return next(ctx, input, handler, opts...)
+		},
+	}.Middleware()
+
+	llm := &mockLLM{responses: []mockResponse{{output: &Output{Content: "hello"}}}}
+	var chunks []string
+	_, err := Chain(llm, upper, inner).CompletionStream(context.Background(), &Input{Model: "m"}, func(content string) {
+		chunks = append(chunks, content)
+	}, MaxTokens(42))
+	if err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	if strings.Join(chunks, "") != "HELLO" {
+		t.Errorf("Expected transformed chunks, got %q", chunks)
+	}
+	if llm.inputs[0].Model != "mapped" {
+		t.Errorf("Expected the modified input to reach the client, got %s", llm.inputs[0].Model)
+	}
+	if options.MaxTokens != 42 || options.Temperature != 0.9 {
+		t.Errorf("Expected per-call options to reach the inner layers, got %+v", options)
+	}
+}
@@ -0,0 +1,64 @@
+package OpenLLM
+
+import (
+	"context"
+	"sync"
+)
+
+// mockResponse 模拟 LLM 的一次返回
+// mockResponse is a scripted result of a mock LLM call
+type mockResponse struct {
+	output *Output
+	err    error
+}
+
+// mockLLM 按顺序返回预设结果的 LLM，用于单元测试
+// mockLLM is an LLM returning scripted results in order, for unit tests
+type mockLLM struct {
+	mu        sync.Mutex
+	provider  ProviderType
+	responses []mockResponse
+	inputs    []*Input
+}
+
+var _ LLM = (*mockLLM)(nil)
+
+func (m *mockLLM) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// 复制消息，避免调用方后续追加消息影响记录
+	recorded := *input
+	recorded.Messages = append([]Message(nil), input.Messages...)
+	m.inputs = append(m.inputs, &recorded)
+
+	if len(m.responses) == 0 {
+		return &Output{Content: "", FinishReason: string(FinishReasonStop)}, nil
+	}
+	response := m.responses[0]
+	if len(m.responses) > 1 {
+		m.responses = m.responses[1:]
+	}
+	if response.err != nil {
+		return response.output, response.err
+	}
+	if input.ResponseFormat != nil {
+		return validateStructuredOutput(m.provider, input, response.output)
+	}
+	return response.output, nil
+}
+
+func (m *mockLLM) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	// 出错时也输出已有的内容，模拟中途失败的流
+	output, err := m.Completion(ctx, input, opts...)
+	if output != nil && streamOutput != nil && output.Content != "" {
+		streamOutput(output.Content)
+	}
+	return output, err
+}
+
+func (m *mockLLM) Provider() ProviderInfo {
+	return ProviderInfo{Type: m.provider, Name: "mock"}
+}
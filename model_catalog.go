@@ -0,0 +1,242 @@
+package OpenLLM
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ============================================================================
+// 模型目录 / Model Catalog
+// ============================================================================
+
+// ModelPricing 模型价格，单位为每百万 token 的美元价格
+// ModelPricing is the price of a model in US dollars per million tokens
+type ModelPricing struct {
+	Input       float64 `json:"input"`                  // 输入 token 价格 / Input token price
+	Output      float64 `json:"output"`                 // 输出 token 价格（包含思考 token）/ Output token price, including thinking tokens
+	CachedInput float64 `json:"cached_input,omitempty"` // 命中缓存的输入 token 价格，0 表示按输入价格计算 / Cached input token price, 0 means the input price
+}
+
+// ModelInfo 模型信息
+// ModelInfo describes a model
+type ModelInfo struct {
+	Name            string               `json:"name"`              // 模型名称 / Model name
+	Provider        ProviderType         `json:"provider"`          // 提供商类型 / Provider type
+	ContextWindow   int64                `json:"context_window"`    // 上下文窗口（token）/ Context window in tokens
+	MaxOutputTokens int64                `json:"max_output_tokens"` // 最大输出 token 数 / Maximum output tokens
+	Capabilities    ProviderCapabilities `json:"capabilities"`      // 能力标识 / Capability flags
+	Pricing         ModelPricing         `json:"pricing"`           // 价格，全为 0 表示未知 / Pricing, all zero when unknown
+}
+
+// Cost 根据 token 使用量计算调用费用（美元），缓存命中的输入 token 按缓存价格计算
+// Cost computes the cost of a call in US dollars from its token usage, charging cached input tokens at the cached price
+func (m ModelInfo) Cost(usage TokenUsage) float64 {
+	cachedPrice := m.Pricing.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = m.Pricing.Input
+	}
+	uncached := usage.InputTokens - usage.CachedTokens
+	return (float64(uncached)*m.Pricing.Input +
+		float64(usage.CachedTokens)*cachedPrice +
+		float64(usage.OutputTokens)*m.Pricing.Output) / 1e6
+}
+
+// 常用能力组合 / Common capability sets
+var (
+	// chatCapabilities 支持工具、视觉和 JSON Schema 的对话模型 / Chat models with tools, vision and JSON schema
+	chatCapabilities = ProviderCapabilities{
+		ToolCall: true, Streaming: true, Temperature: true, TopP: true, Seed: true, SystemMessage: true,
+		Vision: true, JSONSchema: true,
+	}
+	// reasoningCapabilities 不支持采样参数的推理模型 / Reasoning models without sampling parameters
+	reasoningCapabilities = ProviderCapabilities{
+		ToolCall: true, Thinking: true, Streaming: true, SystemMessage: true, Vision: true, JSONSchema: true,
+	}
+)
+
+var (
+	modelsMu sync.RWMutex
+	models   = map[string]ModelInfo{
+		// OpenAI
+		"gpt-4o": {Provider: ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 16384,
+			Capabilities: chatCapabilities, Pricing: ModelPricing{Input: 2.5, Output: 10, CachedInput: 1.25}},
+		"gpt-4o-mini": {Provider: ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 16384,
+			Capabilities: chatCapabilities, Pricing: ModelPricing{Input: 0.15, Output: 0.6, CachedInput: 0.075}},
+		"gpt-4.1": {Provider: ProviderOpenAI, ContextWindow: 1047576, MaxOutputTokens: 32768,
+			Capabilities: chatCapabilities, Pricing: ModelPricing{Input: 2, Output: 8, CachedInput: 0.5}},
+		"gpt-4.1-mini": {Provider: ProviderOpenAI, ContextWindow: 1047576, MaxOutputTokens: 32768,
+			Capabilities: chatCapabilities, Pricing: ModelPricing{Input: 0.4, Output: 1.6, CachedInput: 0.1}},
+		"gpt-5": {Provider: ProviderOpenAI, ContextWindow: 400000, MaxOutputTokens: 128000,
+			Capabilities: reasoningCapabilities, Pricing: ModelPricing{Input: 1.25, Output: 10, CachedInput: 0.125}},
+		"gpt-5-mini": {Provider: ProviderOpenAI, ContextWindow: 400000, MaxOutputTokens: 128000,
+			Capabilities: reasoningCapabilities, Pricing: ModelPricing{Input: 0.25, Output: 2, CachedInput: 0.025}},
+		"o3": {Provider: ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000,
+			Capabilities: reasoningCapabilities, Pricing: ModelPricing{Input: 2, Output: 8, CachedInput: 0.5}},
+		"o4-mini": {Provider: ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000,
+			Capabilities: reasoningCapabilities, Pricing: ModelPricing{Input: 1.1, Output: 4.4, CachedInput: 0.275}},
+
+		// Anthropic Claude（思考需要显式开启，且不支持 Seed）/ Thinking must be enabled explicitly; no seed support
+		"claude-opus-4-1": {Provider: ProviderClaude, ContextWindow: 200000, MaxOutputTokens: 32000,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				SystemMessage: true, Vision: true, JSONSchema: true},
+			Pricing: ModelPricing{Input: 15, Output: 75, CachedInput: 1.5}},
+		"claude-sonnet-4-5": {Provider: ProviderClaude, ContextWindow: 200000, MaxOutputTokens: 64000,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				SystemMessage: true, Vision: true, JSONSchema: true},
+			Pricing: ModelPricing{Input: 3, Output: 15, CachedInput: 0.3}},
+		"claude-haiku-4-5": {Provider: ProviderClaude, ContextWindow: 200000, MaxOutputTokens: 64000,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				SystemMessage: true, Vision: true, JSONSchema: true},
+			Pricing: ModelPricing{Input: 1, Output: 5, CachedInput: 0.1}},
+
+		// Google Gemini（价格为不超过 200K 输入 token 的档位）/ Prices are for prompts up to 200K tokens
+		"gemini-2.5-pro": {Provider: ProviderGemini, ContextWindow: 1048576, MaxOutputTokens: 65536,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				Seed: true, SystemMessage: true, Vision: true, JSONSchema: true},
+			Pricing: ModelPricing{Input: 1.25, Output: 10, CachedInput: 0.31}},
+		Gemini25Flash: {Provider: ProviderGemini, ContextWindow: 1048576, MaxOutputTokens: 65536,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				Seed: true, SystemMessage: true, Vision: true, JSONSchema: true},
+			Pricing: ModelPricing{Input: 0.3, Output: 2.5, CachedInput: 0.075}},
+
+		// 中国大模型（OpenAI 兼容端点）/ Chinese models (OpenAI compatible endpoints)
+		"deepseek-chat": {Provider: ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 8192,
+			Capabilities: ProviderCapabilities{ToolCall: true, Streaming: true, Temperature: true, TopP: true, SystemMessage: true},
+			Pricing:      ModelPricing{Input: 0.28, Output: 0.42, CachedInput: 0.028}},
+		"deepseek-reasoner": {Provider: ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 65536,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, SystemMessage: true},
+			Pricing:      ModelPricing{Input: 0.28, Output: 0.42, CachedInput: 0.028}},
+		DeepseekV31Terminus: {Provider: ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 8192,
+			Capabilities: ProviderCapabilities{ToolCall: true, Streaming: true, Temperature: true, TopP: true, SystemMessage: true},
+			Pricing:      ModelPricing{Input: 0.56, Output: 1.68, CachedInput: 0.07}},
+		KimiK2Thinking: {Provider: ProviderOpenAI, ContextWindow: 262144, MaxOutputTokens: 32768,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				SystemMessage: true},
+			Pricing: ModelPricing{Input: 0.6, Output: 2.5, CachedInput: 0.15}},
+		Qwen3VL235BA22BThinking: {Provider: ProviderOpenAI, ContextWindow: 262144, MaxOutputTokens: 32768,
+			Capabilities: ProviderCapabilities{ToolCall: true, Thinking: true, Streaming: true, Temperature: true, TopP: true,
+				SystemMessage: true, Vision: true}},
+		Qwen3VL235BA22BInstruct: {Provider: ProviderOpenAI, ContextWindow: 262144, MaxOutputTokens: 32768,
+			Capabilities: ProviderCapabilities{ToolCall: true, Streaming: true, Temperature: true, TopP: true, Seed: true,
+				SystemMessage: true, Vision: true}},
+	}
+)
+
+// init 为内置模型补充名称
+// init fills in the names of the built-in models
+func init() {
+	for name, info := range models {
+		info.Name = name
+		models[name] = info
+	}
+}
+
+// RegisterModel 注册模型信息，已存在的同名模型会被覆盖
+// RegisterModel registers model information, replacing any existing model with the same name
+func RegisterModel(info ModelInfo) {
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	models[info.Name] = info
+}
+
+// modelVersionSuffix 匹配模型名称末尾的日期或版本后缀，如 "-2024-08-06"、"-20250929" 和 "-latest"
+// modelVersionSuffix matches a trailing date or version suffix such as "-2024-08-06", "-20250929" and "-latest"
+var modelVersionSuffix = regexp.MustCompile(`(?i)(-\d{4}-\d{2}-\d{2}|-\d{8}|-latest)$`)
+
+// LookupModel 查找模型信息：先按名称精确匹配（不区分大小写），再去掉日期或版本后缀后匹配，
+// 使 "gpt-4o-2024-08-06" 匹配到 "gpt-4o"；"gpt-4.1-nano" 等其他变体需要单独注册
+// LookupModel finds model information, first by exact name (case-insensitive) and then with the date or version
+// suffix removed, so that "gpt-4o-2024-08-06" matches "gpt-4o"; other variants such as "gpt-4.1-nano" must be
+// registered separately
+func LookupModel(name string) (ModelInfo, bool) {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	if name == "" {
+		return ModelInfo{}, false
+	}
+	if info, ok := findModel(name); ok {
+		return info, true
+	}
+	if base := modelVersionSuffix.ReplaceAllString(name, ""); base != name {
+		return findModel(base)
+	}
+	return ModelInfo{}, false
+}
+
+// findModel 按名称查找模型（不区分大小写），调用方需持有读锁
+// findModel finds a model by name (case-insensitive); the caller must hold the read lock
+func findModel(name string) (ModelInfo, bool) {
+	if info, ok := models[name]; ok {
+		return info, true
+	}
+	for key, info := range models {
+		if strings.EqualFold(key, name) {
+			return info, true
+		}
+	}
+	return ModelInfo{}, false
+}
+
+// Models 返回所有已注册的模型信息（按名称排序）
+// Models returns every registered model, sorted by name
+func Models() []ModelInfo {
+	modelsMu.RLock()
+	defer modelsMu.RUnlock()
+	list := make([]ModelInfo, 0, len(models))
+	for _, info := range models {
+		list = append(list, info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LoadModels 从 JSON 加载模型目录并注册，JSON 为以模型名称为键的对象，同名模型整体覆盖
+// 解析失败时不会注册任何模型
+// LoadModels loads a model catalog from JSON and registers it; the JSON is an object keyed by model name,
+// and entries replace existing models with the same name as a whole
+// Nothing is registered when the JSON cannot be parsed
+func LoadModels(r io.Reader) error {
+	var catalog map[string]ModelInfo
+	if err := json.NewDecoder(r).Decode(&catalog); err != nil {
+		return fmt.Errorf("解析模型目录失败: %w", err)
+	}
+
+	modelsMu.Lock()
+	defer modelsMu.Unlock()
+	for name, info := range catalog {
+		info.Name = name
+		models[name] = info
+	}
+	return nil
+}
+
+// LoadModelsFile 从 JSON 文件加载模型目录，格式见 LoadModels
+// LoadModelsFile loads a model catalog from a JSON file; see LoadModels for the format
+func LoadModelsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开模型目录失败: %w", err)
+	}
+	defer f.Close()
+	return LoadModels(f)
+}
+
+// providerInfo 为提供商信息补充客户端选项、默认模型和 URL，并使用模型目录中的能力标识
+// providerInfo completes the provider information with the client options, default model and URL, taking the
+// capability flags from the model catalog
+func providerInfo(opts []Option, info ProviderInfo, extends ...Option) ProviderInfo {
+	o := newOptions(opts, extends...)
+	info.Model = o.Model
+	info.BaseURL = o.URL
+	info.options = opts
+	if model, ok := LookupModel(o.Model); ok {
+		info.Capabilities = model.Capabilities
+	}
+	return info
+}
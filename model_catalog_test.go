@@ -0,0 +1,126 @@
+package OpenLLM
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupModel(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		want  string
+	}{
+		{"精确匹配", "gpt-4o", "gpt-4o"},
+		{"带日期的版本", "gpt-4o-2024-08-06", "gpt-4o"},
+		{"带日期的小模型", "gpt-4o-mini-2024-07-18", "gpt-4o-mini"},
+		{"紧凑日期", "claude-sonnet-4-5-20250929", "claude-sonnet-4-5"},
+		{"latest后缀", "gpt-4o-latest", "gpt-4o"},
+		{"不区分大小写", "Claude-Sonnet-4-5", "claude-sonnet-4-5"},
+		{"常量", Gemini25Flash, Gemini25Flash},
+		{"未知模型", "gpt-4", ""},
+		{"非分隔前缀", "o3x", ""},
+		{"nano变体", "gpt-4.1-nano", ""},
+		{"mini变体", "o3-mini", ""},
+		{"lite变体", "gemini-2.5-flash-lite", ""},
+		{"gpt-5-nano变体", "gpt-5-nano", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, ok := LookupModel(tt.model)
+			if ok != (tt.want != "") || info.Name != tt.want {
+				t.Errorf("LookupModel(%q) = %q, %v, want %q", tt.model, info.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelInfo_Cost(t *testing.T) {
+	info, _ := LookupModel("gpt-4o")
+	// 1000 个未缓存输入 × 2.5 + 1000 个缓存输入 × 1.25 + 500 个输出 × 10，单位为每百万 token
+	cost := info.Cost(TokenUsage{InputTokens: 2000, CachedTokens: 1000, OutputTokens: 500})
+	if want := (1000*2.5 + 1000*1.25 + 500*10) / 1e6; math.Abs(cost-want) > 1e-12 {
+		t.Errorf("Cost() = %v, want %v", cost, want)
+	}
+
+	// 未设置缓存价格时按输入价格计算
+	noCache := ModelInfo{Pricing: ModelPricing{Input: 1, Output: 2}}
+	if cost := noCache.Cost(TokenUsage{InputTokens: 1e6, CachedTokens: 5e5, OutputTokens: 1e6}); cost != 3 {
+		t.Errorf("Cost() without cached price = %v, want 3", cost)
+	}
+}
+
+func TestLoadModelsFile(t *testing.T) {
+	original, _ := LookupModel("gpt-4o")
+	t.Cleanup(func() {
+		RegisterModel(original)
+		modelsMu.Lock()
+		delete(models, KimiK2Instruct)
+		modelsMu.Unlock()
+	})
+
+	path := filepath.Join(t.TempDir(), "models.json")
+	catalog := `{
+		"kimi-k2-instruct-0905-local": {
+			"provider": "openai", "context_window": 131072, "max_output_tokens": 16384,
+			"capabilities": {"tool_call": true, "streaming": true, "system_message": true}
+		},
+		"gpt-4o": {
+			"provider": "azure", "context_window": 128000, "max_output_tokens": 4096,
+			"pricing": {"input": 2, "output": 8}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(catalog), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadModelsFile(path); err != nil {
+		t.Fatalf("LoadModelsFile() error = %v", err)
+	}
+
+	local, ok := LookupModel(KimiK2Instruct)
+	if !ok || local.Name != KimiK2Instruct || local.ContextWindow != 131072 || !local.Capabilities.ToolCall {
+		t.Errorf("Expected the local model to be registered, got %+v", local)
+	}
+	overridden, _ := LookupModel("gpt-4o-2024-08-06")
+	if overridden.Provider != ProviderAzure || overridden.MaxOutputTokens != 4096 || overridden.Pricing.Input != 2 {
+		t.Errorf("Expected gpt-4o to be overridden, got %+v", overridden)
+	}
+
+	if err := LoadModels(strings.NewReader(`{"broken": `)); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if err := LoadModelsFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestProvider_Capabilities(t *testing.T) {
+	openai := CreateOpenAI(Model("gpt-4o-2024-08-06"), APIKey("test")).Provider()
+	if openai.Type != ProviderOpenAI || openai.Model != "gpt-4o-2024-08-06" || !openai.Capabilities.Vision || !openai.Capabilities.Seed {
+		t.Errorf("Unexpected OpenAI provider info: %+v", openai)
+	}
+
+	claude := CreateAnthropic(Model("claude-sonnet-4-5"), APIKey("test")).Provider()
+	if !claude.Capabilities.Thinking || !claude.Capabilities.ToolCall || claude.Capabilities.Seed {
+		t.Errorf("Unexpected Claude capabilities: %+v", claude.Capabilities)
+	}
+
+	// Azure 会忽略采样参数 / Azure drops sampling parameters
+	azure := CreateAzure(Model("gpt-4o"), APIKey("test")).Provider()
+	if !azure.Capabilities.Vision || azure.Capabilities.Temperature || azure.Capabilities.Seed {
+		t.Errorf("Unexpected Azure capabilities: %+v", azure.Capabilities)
+	}
+	custom := CreateAzure(Model("my-deployment"), APIKey("test")).Provider()
+	if !custom.Capabilities.ToolCall || custom.Capabilities.Temperature || custom.Capabilities.TopP || custom.Capabilities.Seed {
+		t.Errorf("Unexpected Azure capabilities for a model missing from the catalog: %+v", custom.Capabilities)
+	}
+
+	// 目录中没有的模型保持默认能力 / Models missing from the catalog keep the default capabilities
+	unknown := CreateAnthropic(Model("claude-internal"), APIKey("test")).Provider()
+	if unknown.Model != "claude-internal" || unknown.Capabilities != (ProviderCapabilities{}) {
+		t.Errorf("Unexpected provider info for an unknown model: %+v", unknown)
+	}
+}
@@ -2,8 +2,12 @@ package OpenLLM
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,9 +15,14 @@ import (
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
 	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/packages/respjson"
+	"github.com/openai/openai-go/v3/shared"
 )
 
-var _ LLM = (*OpenAI)(nil)
+var (
+	_ LLM           = (*OpenAI)(nil)
+	_ EventStreamer = (*OpenAI)(nil)
+)
 
 // ============================================================================
 // OpenAI SDK客户端封装 / OpenAI SDK Client Wrapper
@@ -34,7 +43,7 @@ func CreateOpenAI(opts ...Option) *OpenAI {
 	client := openai.NewClient(
 		option.WithBaseURL(options.URL),
 		option.WithAPIKey(options.APIKey),
-		option.WithHTTPClient(requests.New().HTTPClient(options.HTTPClientOptions...)),
+		option.WithHTTPClient(requests.New().HTTPClient(options.httpClientOptions()...)),
 	)
 
 	return &OpenAI{
@@ -58,6 +67,12 @@ func (o *OpenAI) ChatCompletion(ctx context.Context, params openai.ChatCompletio
 // chatCompletionStream calls OpenAI Chat Completion API (streaming)
 // 返回Stream接口
 func (o *OpenAI) ChatCompletionStream(ctx context.Context, params openai.ChatCompletionNewParams, streamOutput StreamOutput) (*openai.ChatCompletion, error) {
+	return o.ChatCompletionStreamEvents(ctx, params, streamOutput.Handler())
+}
+
+// ChatCompletionStreamEvents 调用OpenAI Chat Completion API（流式），以类型化事件输出文本和工具调用增量
+// ChatCompletionStreamEvents calls OpenAI Chat Completion API (streaming), emitting text and tool call deltas as typed events
+func (o *OpenAI) ChatCompletionStreamEvents(ctx context.Context, params openai.ChatCompletionNewParams, handler StreamHandler) (*openai.ChatCompletion, error) {
 
 	// 创建流式请求
 	stream := o.client.Chat.Completions.NewStreaming(ctx, params, option.WithJSONSet("stream", true))
@@ -73,6 +88,9 @@ func (o *OpenAI) ChatCompletionStream(ctx context.Context, params openai.ChatCom
 		Usage:   openai.CompletionUsage{},
 	}
 
+	// 思考模型的推理内容（reasoning_content 等非标准字段）
+	var reasoning strings.Builder
+
 	// 工具调用的临时存储（因为stream中tool_call是增量的）
 	tools := make(map[int64]*openai.ChatCompletionMessageToolCallUnion)
 
@@ -100,27 +118,34 @@ func (o *OpenAI) ChatCompletionStream(ctx context.Context, params openai.ChatCom
 			// 累积content并实时输出
 			if delta.Content != "" {
 				content.WriteString(delta.Content)
-				if streamOutput != nil {
-					streamOutput(delta.Content)
-				}
+				handler.content(delta.Content)
+			}
+			if text := openAIReasoning(delta.JSON.ExtraFields); text != "" {
+				reasoning.WriteString(text)
+				handler.thinking(text)
 			}
 
 			// 累积tool_calls（增量式）
 			for _, delta := range delta.ToolCalls {
+				handler.toolCall(ToolCallDelta{
+					Index:     int(delta.Index),
+					ID:        delta.ID,
+					Name:      delta.Function.Name,
+					Arguments: delta.Function.Arguments,
+				})
 				tool, ok := tools[delta.Index]
 				if !ok {
-					tool = &openai.ChatCompletionMessageToolCallUnion{
-						ID:   delta.ID,
-						Type: "function",
-						Function: openai.ChatCompletionMessageFunctionToolCallFunction{
-							Name:      delta.Function.Name,
-							Arguments: delta.Function.Arguments,
-						},
-					}
-				} else {
-					tool.Function.Arguments += delta.Function.Arguments
+					tool = &openai.ChatCompletionMessageToolCallUnion{Type: "function"}
+					tools[delta.Index] = tool
+				}
+				// 部分提供商会在后续增量中重复发送ID和名称，以最后一次非空值为准，不做拼接
+				if delta.ID != "" {
+					tool.ID = delta.ID
+				}
+				if delta.Function.Name != "" {
+					tool.Function.Name = delta.Function.Name
 				}
-				tools[delta.Index] = tool
+				tool.Function.Arguments += delta.Function.Arguments
 			}
 
 			// 保存finish_reason（最后一个chunk才有）
@@ -129,8 +154,8 @@ func (o *OpenAI) ChatCompletionStream(ctx context.Context, params openai.ChatCom
 			}
 		}
 
-		// 保存usage（最后一个chunk才有）
-		if chunk.Usage.CompletionTokens > 0 || chunk.Usage.PromptTokens > 0 {
+		// 保存usage（开启 include_usage 后由最后一个 choices 为空的chunk返回）
+		if chunk.JSON.Usage.Valid() {
 			completion.Usage = chunk.Usage
 		}
 	}
@@ -143,14 +168,19 @@ func (o *OpenAI) ChatCompletionStream(ctx context.Context, params openai.ChatCom
 	// 设置最终的内容和工具调用
 	if len(completion.Choices) > 0 {
 		completion.Choices[0].Message.Content = content.String()
+		if reasoning.Len() > 0 {
+			// 与非流式响应保持一致，推理内容放入消息的扩展字段
+			raw, _ := json.Marshal(reasoning.String())
+			completion.Choices[0].Message.JSON.ExtraFields = map[string]respjson.Field{
+				"reasoning_content": respjson.NewField(string(raw)),
+			}
+		}
 
-		// 将map转换为slice（按index排序）
+		// 将map转换为slice（按index排序，保证并行工具调用的顺序稳定）
 		if len(tools) > 0 {
 			completion.Choices[0].Message.ToolCalls = make([]openai.ChatCompletionMessageToolCallUnion, 0, len(tools))
-			for _, tool := range tools {
-				if tool != nil {
-					completion.Choices[0].Message.ToolCalls = append(completion.Choices[0].Message.ToolCalls, *tool)
-				}
+			for _, index := range slices.Sorted(maps.Keys(tools)) {
+				completion.Choices[0].Message.ToolCalls = append(completion.Choices[0].Message.ToolCalls, *tools[index])
 			}
 		}
 	}
@@ -174,7 +204,7 @@ func (o *OpenAI) Completion(ctx context.Context, input *Input, opts ...Option) (
 	// 3. 调用底层SDK（使用原生类型）
 	completion, err := o.ChatCompletion(ctx, params)
 	if err != nil {
-		return nil, NewLLMError(ProviderOpenAI, "API_ERROR", "OpenAI API调用失败", err)
+		return nil, newAPIError(ProviderOpenAI, "OpenAI API调用失败", err)
 	}
 
 	// 5. 检查响应
@@ -183,27 +213,81 @@ func (o *OpenAI) Completion(ctx context.Context, input *Input, opts ...Option) (
 	}
 
 	// 6. 适配：SDK原生类型 → Union类型
-	return fromOpenAIResponse(completion, time.Since(startTime)), nil
+	output, err := fromOpenAIResponse(completion, time.Since(startTime))
+	if err != nil {
+		return nil, NewLLMError(ProviderOpenAI, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderOpenAI, input, output)
 }
 
 // CompletionStream 执行单次对话完成（流式）
 // CompletionStream performs a single conversation completion (streaming)
 func (o *OpenAI) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return o.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+func (o *OpenAI) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
 	// 1. 适配：Union类型 → SDK原生类型
 	params, err := o.GenerateOpenAIChatCompletionNewParams(input, opts...)
 	if err != nil {
 		return nil, NewLLMError(ProviderOpenAI, "CONVERT_ERROR", "转换请求参数失败", err)
 	}
 
+	setStreamUsage(&params, newOptions(o.options, opts...))
+
 	// 2. 记录开始时间
 	startTime := time.Now()
 
-	// 3. 调用底层SDK（使用原生类型）
-	completion, err := o.ChatCompletionStream(ctx, params, streamOutput)
+	// 3. 调用底层SDK（使用原生类型），流中断时返回已收到的部分输出
+	recorder := newStreamRecorder()
+	handler = recorder.wrap(handler)
+	completion, err := o.ChatCompletionStreamEvents(ctx, params, handler)
+	if err != nil {
+		return recorder.interrupt(ctx, handler, newAPIError(ProviderOpenAI, "OpenAI API调用失败", err))
+	}
+	if len(completion.Choices) == 0 {
+		return nil, NewLLMError(ProviderOpenAI, "EMPTY_RESPONSE", "OpenAI返回空响应", nil)
+	}
+
+	output, err := fromOpenAIResponse(completion, time.Since(startTime))
+	recorder.finish(handler, output)
+	if err != nil {
+		return output, NewLLMError(ProviderOpenAI, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderOpenAI, input, output)
+}
+
+// Provider 获取提供商信息
+// Provider returns the provider information
+func (o *OpenAI) Provider() ProviderInfo {
+	return providerInfo(o.options, ProviderInfo{
+		Type:       ProviderOpenAI,
+		Name:       "OpenAI",
+		Version:    "v1",
+		parameters: o.requestParameters,
+	})
+}
+
+// requestParameters 返回请求实际发送的生成参数
+// requestParameters returns the generation parameters actually sent with the request
+func (o *OpenAI) requestParameters(input *Input, stream bool, opts []Option) requestParameters {
+	params, err := o.GenerateOpenAIChatCompletionNewParams(&Input{Model: input.Model}, opts...)
 	if err != nil {
-		return nil, NewLLMError(ProviderOpenAI, "API_ERROR", "OpenAI API调用失败", err)
+		return requestParameters{}
+	}
+	return openAIRequestParameters(params)
+}
+
+// openAIRequestParameters 从 Chat Completions 请求参数中提取生成参数
+// openAIRequestParameters extracts the generation parameters from Chat Completions request parameters
+func openAIRequestParameters(params openai.ChatCompletionNewParams) requestParameters {
+	return requestParameters{
+		Temperature: sdkParameter[float64](params.Temperature),
+		TopP:        sdkParameter[float64](params.TopP),
+		MaxTokens:   sdkParameter[int64](params.MaxCompletionTokens),
 	}
-	return fromOpenAIResponse(completion, time.Since(startTime)), nil
 }
 
 // ============================================================================
@@ -229,14 +313,14 @@ func (o *OpenAI) GenerateOpenAIChatCompletionNewParams(input *Input, opts ...Opt
 	// 2. 构建基础参数
 	params := openai.ChatCompletionNewParams{
 		Messages:            messages,
-		Model:               input.Model,
+		Model:               options.modelName(input),
 		Temperature:         openai.Float(options.Temperature),
 		MaxCompletionTokens: openai.Int(options.MaxTokens),
 		TopP:                openai.Float(options.TopP),
 	}
 
 	// Gemini模型不支持Seed参数
-	if !strings.HasPrefix(strings.ToLower(input.Model), "gemini") {
+	if !strings.HasPrefix(strings.ToLower(params.Model), "gemini") {
 		params.Seed = openai.Int(options.Seed)
 	}
 
@@ -252,27 +336,51 @@ func (o *OpenAI) GenerateOpenAIChatCompletionNewParams(input *Input, opts ...Opt
 		params.Tools = append(params.Tools, openaiTool)
 	}
 
+	// 结构化输出使用 json_schema，Schema 支持时启用严格模式
+	if format := input.ResponseFormat; format != nil {
+		if err := format.Validate(); err != nil {
+			return openai.ChatCompletionNewParams{}, fmt.Errorf("结构化输出格式验证失败: %w", err)
+		}
+		schema, strict := format.openAISchema()
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+				JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+					Name:        format.name(),
+					Description: openai.String(format.Description),
+					Schema:      schema,
+					Strict:      openai.Bool(strict),
+				},
+			},
+		}
+	}
+
 	return params, nil
 }
 
 // fromOpenAIResponse 将OpenAI SDK响应转换为Union响应
+// 工具调用参数不是有效的 JSON 对象时返回错误
 // fromOpenAIResponse converts OpenAI SDK response to Union response
-func fromOpenAIResponse(completion *openai.ChatCompletion, duration time.Duration) *Output {
+// An error is returned when tool call arguments are not a valid JSON object
+func fromOpenAIResponse(completion *openai.ChatCompletion, duration time.Duration) (*Output, error) {
 	choice := completion.Choices[0]
 	message := choice.Message
 
 	// 构建响应
 	output := &Output{
 		Content:      message.Content,
+		Thinking:     openAIReasoning(message.JSON.ExtraFields),
 		FinishReason: choice.FinishReason,
 		Cost:         duration,
 		RawResponse:  completion,
 	}
 
-	// 转换工具调用
+	// 转换工具调用，参数无效时仍保留其余输出并返回错误
+	var errs []error
 	for _, tc := range message.ToolCalls {
-		var args map[string]any
-		json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		args, err := parseToolArguments(tc.Function.Arguments)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("工具 %s（%s）: %w", tc.Function.Name, tc.ID, err))
+		}
 		output.ToolCalls = append(output.ToolCalls, ToolCall{
 			ID:        tc.ID,
 			Name:      tc.Function.Name,
@@ -282,12 +390,60 @@ func fromOpenAIResponse(completion *openai.ChatCompletion, duration time.Duratio
 
 	// 添加Token使用情况
 	output.TokenUsage = TokenUsage{
-		InputTokens:  int64(completion.Usage.PromptTokens),
-		OutputTokens: int64(completion.Usage.CompletionTokens),
-		TotalTokens:  int64(completion.Usage.PromptTokens + completion.Usage.CompletionTokens),
+		InputTokens:    int64(completion.Usage.PromptTokens),
+		CachedTokens:   completion.Usage.PromptTokensDetails.CachedTokens,
+		ThinkingTokens: completion.Usage.CompletionTokensDetails.ReasoningTokens,
+		OutputTokens:   int64(completion.Usage.CompletionTokens),
+		TotalTokens:    int64(completion.Usage.PromptTokens + completion.Usage.CompletionTokens),
+	}
+
+	return output, errors.Join(errs...)
+}
+
+// setStreamUsage 除非通过 DisableStreamUsage 关闭，否则为流式请求设置 stream_options.include_usage
+// OpenAI 和 Azure 只有设置该参数时才会在流式响应中返回 token 使用量
+// setStreamUsage sets stream_options.include_usage on a streaming request unless disabled with DisableStreamUsage
+// OpenAI and Azure only return token usage on streams when the parameter is set
+func setStreamUsage(params *openai.ChatCompletionNewParams, options *Options) {
+	if !options.DisableStreamUsage {
+		params.StreamOptions.IncludeUsage = openai.Bool(true)
+	}
+}
+
+// parseToolArguments 解析工具调用的参数 JSON，空字符串视为无参数
+// parseToolArguments parses the argument JSON of a tool call, treating an empty string as no arguments
+func parseToolArguments(arguments string) (map[string]any, error) {
+	if strings.TrimSpace(arguments) == "" {
+		return map[string]any{}, nil
+	}
+	var args map[string]any
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return nil, fmt.Errorf("参数不是有效的JSON: %w", err)
 	}
+	return args, nil
+}
 
-	return output
+// openAIReasoningFields OpenAI 兼容的思考模型返回推理内容时使用的非标准字段
+// DeepSeek、Kimi、通义千问使用 reasoning_content，部分推理服务（如 vLLM、OpenRouter）使用 reasoning
+// openAIReasoningFields are the non-standard fields OpenAI-compatible thinking models use for reasoning content
+// DeepSeek, Kimi and Qwen use reasoning_content, and some serving stacks (such as vLLM and OpenRouter) use reasoning
+var openAIReasoningFields = []string{"reasoning_content", "reasoning"}
+
+// openAIReasoning 从消息或增量的扩展字段中读取推理内容
+// openAIReasoning reads the reasoning content from the extra fields of a message or delta
+func openAIReasoning(extraFields map[string]respjson.Field) string {
+	for _, name := range openAIReasoningFields {
+		// SDK 未声明的扩展字段不会被标记为 valid，直接读取原始 JSON
+		field, ok := extraFields[name]
+		if !ok || field.Raw() == "" {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal([]byte(field.Raw()), &text); err == nil && text != "" {
+			return text
+		}
+	}
+	return ""
 }
 
 // toOpenAIMessage 将Message消息转换为OpenAI消息
@@ -295,15 +451,22 @@ func fromOpenAIResponse(completion *openai.ChatCompletion, duration time.Duratio
 func toOpenAIMessage(msg Message) (openai.ChatCompletionMessageParamUnion, error) {
 	switch msg.Role {
 	case RoleSystem:
-		return openai.SystemMessage(msg.Content), nil
+		return openai.SystemMessage(msg.Text()), nil
 
 	case RoleUser:
-		return openai.UserMessage(msg.Content), nil
+		if len(msg.Parts) == 0 {
+			return openai.UserMessage(msg.Content), nil
+		}
+		parts, err := toOpenAIContentParts(msg.Parts)
+		if err != nil {
+			return openai.ChatCompletionMessageParamUnion{}, err
+		}
+		return openai.UserMessage(parts), nil
 
 	case RoleAssistant:
 		// Assistant消息需要手动构建，因为可能包含工具调用
 		var assistant openai.ChatCompletionAssistantMessageParam
-		assistant.Content.OfString = param.NewOpt(msg.Content)
+		assistant.Content.OfString = param.NewOpt(msg.Text())
 
 		// 转换工具调用
 		if len(msg.ToolCalls) > 0 {
@@ -325,13 +488,68 @@ func toOpenAIMessage(msg Message) (openai.ChatCompletionMessageParamUnion, error
 		return openai.ChatCompletionMessageParamUnion{OfAssistant: &assistant}, nil
 
 	case RoleTool:
-		return openai.ToolMessage(msg.Content, msg.ToolCallID), nil
+		return openai.ToolMessage(msg.Text(), msg.ToolCallID), nil
 
 	default:
 		return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("不支持的消息角色: %s", msg.Role)
 	}
 }
 
+// toOpenAIContentParts 将多模态内容片段转换为OpenAI内容片段
+// toOpenAIContentParts converts multimodal content parts to OpenAI content parts
+func toOpenAIContentParts(parts []ContentPart) ([]openai.ChatCompletionContentPartUnionParam, error) {
+	result := make([]openai.ChatCompletionContentPartUnionParam, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case PartText:
+			result = append(result, openai.TextContentPart(part.Text))
+
+		case PartImageURL:
+			result = append(result, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: part.URL}))
+
+		case PartImage:
+			// 内联图片以 data URL 形式发送
+			result = append(result, openai.ImageContentPart(openai.ChatCompletionContentPartImageImageURLParam{URL: part.dataURL()}))
+
+		case PartAudio:
+			format, ok := openAIAudioFormat(part.MIMEType)
+			if !ok {
+				return nil, fmt.Errorf("不支持的音频格式: %s", part.MIMEType)
+			}
+			result = append(result, openai.InputAudioContentPart(openai.ChatCompletionContentPartInputAudioInputAudioParam{
+				Data:   base64.StdEncoding.EncodeToString(part.Data),
+				Format: format,
+			}))
+
+		case PartFile:
+			if len(part.Data) == 0 {
+				return nil, fmt.Errorf("OpenAI Chat API 不支持通过URL发送文件: %s", part.URL)
+			}
+			result = append(result, openai.FileContentPart(openai.ChatCompletionContentPartFileFileParam{
+				FileData: openai.String(part.dataURL()),
+				Filename: openai.String(part.FileName),
+			}))
+
+		default:
+			return nil, fmt.Errorf("不支持的内容片段类型: %s", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// openAIAudioFormat 将音频MIME类型转换为OpenAI音频格式
+// openAIAudioFormat converts audio MIME type to OpenAI audio format
+func openAIAudioFormat(mimeType string) (string, bool) {
+	switch mimeType {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return "wav", true
+	case "audio/mpeg", "audio/mp3":
+		return "mp3", true
+	default:
+		return "", false
+	}
+}
+
 // toOpenAIToolChoice 将Tool工具选择转换为OpenAI格式
 // toOpenAIToolChoice converts Union tool choice to OpenAI format
 func toOpenAIToolChoice(choice ToolChoiceOption) openai.ChatCompletionToolChoiceOptionUnionParam {
@@ -0,0 +1,466 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+var (
+	_ LLM           = (*Responses)(nil)
+	_ EventStreamer = (*Responses)(nil)
+)
+
+// ============================================================================
+// OpenAI Responses API客户端封装 / OpenAI Responses API Client Wrapper
+// ============================================================================
+
+// Responses 封装 OpenAI Responses API（/v1/responses），适用于 o1/o3 等推理模型
+// 复用 OpenAI 的 SDK 客户端，仅替换请求与响应的适配逻辑
+// Responses wraps the OpenAI Responses API (/v1/responses) for reasoning models such as o1/o3
+// It reuses the OpenAI SDK client and only replaces the request/response adapter logic
+type Responses struct {
+	client  *OpenAI // 复用OpenAI client
+	options []Option
+}
+
+// CreateOpenAIResponses 创建 OpenAI Responses API 客户端
+// CreateOpenAIResponses creates a new OpenAI Responses API client
+func CreateOpenAIResponses(opts ...Option) *Responses {
+	return &Responses{
+		client:  CreateOpenAI(opts...),
+		options: opts,
+	}
+}
+
+// ============================================================================
+// LLM接口实现 / LLM Interface Implementation
+// ============================================================================
+
+// Completion 执行单次对话完成（非流式）
+// Completion performs a single conversation completion (non-streaming)
+func (r *Responses) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	// 1. 适配：Union类型 → SDK原生类型
+	params, err := r.GenerateResponseNewParams(input, opts...)
+	if err != nil {
+		return nil, NewLLMError(ProviderOpenAIResponses, "CONVERT_ERROR", "转换请求参数失败", err)
+	}
+
+	// 2. 记录开始时间
+	startTime := time.Now()
+
+	// 3. 调用底层SDK（使用原生类型）
+	response, err := r.client.client.Responses.New(ctx, params)
+	if err != nil {
+		return nil, newAPIError(ProviderOpenAIResponses, "OpenAI Responses API调用失败", err)
+	}
+
+	if err := responsesFailure(response); err != nil {
+		return nil, err
+	}
+
+	// 4. 适配：SDK原生类型 → Union类型
+	output, err := fromResponsesResponse(response, startTime)
+	if err != nil {
+		return nil, NewLLMError(ProviderOpenAIResponses, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderOpenAIResponses, input, output)
+}
+
+// CompletionStream 执行单次对话完成（流式）
+// CompletionStream performs a single conversation completion (streaming)
+func (r *Responses) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return r.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出
+// 推理摘要和推理文本的增量作为思考事件输出
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events
+// Reasoning summary and reasoning text deltas are emitted as thinking events
+func (r *Responses) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	params, err := r.GenerateResponseNewParams(input, opts...)
+	if err != nil {
+		return nil, NewLLMError(ProviderOpenAIResponses, "CONVERT_ERROR", "转换请求参数失败", err)
+	}
+
+	startTime := time.Now()
+	stream := r.client.client.Responses.NewStreaming(ctx, params)
+
+	// 流中断时返回已收到的部分输出 / Return the partial output when the stream is interrupted
+	recorder := newStreamRecorder()
+	handler = recorder.wrap(handler)
+
+	var response *responses.Response
+	for stream.Next() {
+		event := stream.Current()
+		switch event.Type {
+		case "response.output_text.delta":
+			handler.content(event.Delta)
+		case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+			handler.thinking(event.Delta)
+		case "response.output_item.added":
+			if event.Item.Type == "function_call" {
+				handler.toolCall(ToolCallDelta{Index: int(event.OutputIndex), ID: event.Item.CallID, Name: event.Item.Name})
+			}
+		case "response.function_call_arguments.delta":
+			handler.toolCall(ToolCallDelta{Index: int(event.OutputIndex), Arguments: event.Delta})
+		case "response.completed", "response.incomplete", "response.failed":
+			// 终止事件携带完整的响应对象
+			response = &event.Response
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return recorder.interrupt(ctx, handler, newAPIError(ProviderOpenAIResponses, "OpenAI Responses API调用失败", err))
+	}
+	if response == nil {
+		return nil, NewLLMError(ProviderOpenAIResponses, "EMPTY_RESPONSE", "OpenAI Responses API返回空响应", nil)
+	}
+
+	if err := responsesFailure(response); err != nil {
+		return recorder.interrupt(ctx, handler, err)
+	}
+
+	// 工具调用参数无效时仍返回已收到的内容和 token 用量
+	output, err := fromResponsesResponse(response, startTime)
+	recorder.finish(handler, output)
+	if err != nil {
+		return output, NewLLMError(ProviderOpenAIResponses, "INVALID_TOOL_ARGUMENTS", "工具调用参数解析失败", err)
+	}
+	return validateStructuredOutput(ProviderOpenAIResponses, input, output)
+}
+
+// Provider 获取提供商信息
+// Provider returns the provider information
+func (r *Responses) Provider() ProviderInfo {
+	return providerInfo(r.options, ProviderInfo{
+		Type:       ProviderOpenAIResponses,
+		Name:       "OpenAI Responses",
+		Version:    "v1",
+		parameters: r.requestParameters,
+	})
+}
+
+// requestParameters 返回请求实际发送的生成参数
+// requestParameters returns the generation parameters actually sent with the request
+func (r *Responses) requestParameters(input *Input, stream bool, opts []Option) requestParameters {
+	params, err := r.GenerateResponseNewParams(&Input{Model: input.Model}, opts...)
+	if err != nil {
+		return requestParameters{}
+	}
+	return requestParameters{
+		Temperature: sdkParameter[float64](params.Temperature),
+		TopP:        sdkParameter[float64](params.TopP),
+		MaxTokens:   sdkParameter[int64](params.MaxOutputTokens),
+	}
+}
+
+// ============================================================================
+// 适配逻辑 / Adapter Logic
+// 将Union类型转换为SDK原生类型，或将SDK原生类型转换为Union类型
+// ============================================================================
+
+// isOpenAIReasoningModel 判断是否为推理模型（o 系列与 gpt-5 系列）
+// isOpenAIReasoningModel reports whether the model is a reasoning model (o-series and gpt-5 series)
+func isOpenAIReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	for _, prefix := range []string{"o1", "o3", "o4", "gpt-5"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// responsesMaxOutputTokens 返回发送给 Responses API 的 max_output_tokens：显式设置时按模型目录中的上限截断，
+// 未设置时使用目录中的上限，模型不在目录中时返回 0，不发送该参数而使用服务端默认值
+// responsesMaxOutputTokens returns the max_output_tokens sent to the Responses API: an explicit value is capped at the
+// limit from the model catalog, otherwise the catalog limit is used, and 0 (parameter omitted, server default) for unknown models
+func responsesMaxOutputTokens(options *Options, model string) int64 {
+	if maxTokens, ok := options.maxTokens(); ok {
+		return capMaxTokens(maxTokens, model)
+	}
+	if info, ok := LookupModel(model); ok {
+		return info.MaxOutputTokens
+	}
+	return 0
+}
+
+// GenerateResponseNewParams 将Union请求转换为OpenAI Responses API原生参数
+// GenerateResponseNewParams converts Union request to OpenAI Responses API native parameters
+func (r *Responses) GenerateResponseNewParams(input *Input, opts ...Option) (responses.ResponseNewParams, error) {
+	options := newOptions(r.options, opts...)
+
+	params := responses.ResponseNewParams{
+		Model: options.modelName(input),
+	}
+	if maxTokens := responsesMaxOutputTokens(options, params.Model); maxTokens > 0 {
+		params.MaxOutputTokens = openai.Int(maxTokens)
+	}
+
+	// 推理模型不支持采样参数，但可以返回推理摘要
+	if isOpenAIReasoningModel(params.Model) {
+		params.Reasoning = shared.ReasoningParam{Summary: shared.ReasoningSummaryAuto}
+	} else {
+		params.Temperature = openai.Float(options.Temperature)
+		if options.TopP > 0 {
+			params.TopP = openai.Float(options.TopP)
+		}
+	}
+
+	if options.PreviousResponseID != "" {
+		params.PreviousResponseID = openai.String(options.PreviousResponseID)
+	}
+
+	// 1. 转换消息：系统消息合并为 instructions，其余消息转换为输入项
+	var instructions []string
+	items := make(responses.ResponseInputParam, 0, len(input.Messages))
+	for _, msg := range input.Messages {
+		if msg.Role == RoleSystem {
+			instructions = append(instructions, msg.Text())
+			continue
+		}
+		msgItems, err := toResponsesInputItems(msg)
+		if err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+		items = append(items, msgItems...)
+	}
+	if len(instructions) > 0 {
+		params.Instructions = openai.String(strings.Join(instructions, "\n\n"))
+	}
+	params.Input = responses.ResponseNewParamsInputUnion{OfInputItemList: items}
+
+	// 2. 转换工具定义
+	for _, tool := range input.Tools {
+		responsesTool, err := tool.ToOpenAIResponsesTool()
+		if err != nil {
+			return responses.ResponseNewParams{}, fmt.Errorf("转换工具 %s 失败: %w", tool.Name, err)
+		}
+		params.Tools = append(params.Tools, responsesTool)
+	}
+
+	// 3. 转换工具选择策略
+	if input.ToolChoice != nil {
+		params.ToolChoice = toResponsesToolChoice(*input.ToolChoice)
+	}
+
+	// 4. 结构化输出使用 json_schema，Schema 支持时启用严格模式
+	if format := input.ResponseFormat; format != nil {
+		if err := format.Validate(); err != nil {
+			return responses.ResponseNewParams{}, fmt.Errorf("结构化输出格式验证失败: %w", err)
+		}
+		schema, strict := format.openAISchema()
+		params.Text = responses.ResponseTextConfigParam{
+			Format: responses.ResponseFormatTextConfigUnionParam{
+				OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+					Name:        format.name(),
+					Description: openai.String(format.Description),
+					Schema:      schema,
+					Strict:      openai.Bool(strict),
+				},
+			},
+		}
+	}
+
+	return params, nil
+}
+
+// toResponsesInputItems 将Message消息转换为Responses API输入项
+// toResponsesInputItems converts Message message to Responses API input items
+func toResponsesInputItems(msg Message) ([]responses.ResponseInputItemUnionParam, error) {
+	switch msg.Role {
+	case RoleUser:
+		if len(msg.Parts) == 0 {
+			return []responses.ResponseInputItemUnionParam{
+				responses.ResponseInputItemParamOfMessage(msg.Content, responses.EasyInputMessageRoleUser),
+			}, nil
+		}
+		content, err := toResponsesContentList(msg.Parts)
+		if err != nil {
+			return nil, err
+		}
+		return []responses.ResponseInputItemUnionParam{
+			responses.ResponseInputItemParamOfMessage(content, responses.EasyInputMessageRoleUser),
+		}, nil
+
+	case RoleAssistant:
+		// 助手消息的文本与工具调用在 Responses API 中是独立的输入项
+		var items []responses.ResponseInputItemUnionParam
+		if text := msg.Text(); text != "" {
+			items = append(items, responses.ResponseInputItemParamOfMessage(text, responses.EasyInputMessageRoleAssistant))
+		}
+		for _, tc := range msg.ToolCalls {
+			argsJSON, _ := json.Marshal(tc.Arguments)
+			items = append(items, responses.ResponseInputItemParamOfFunctionCall(string(argsJSON), tc.ID, tc.Name))
+		}
+		return items, nil
+
+	case RoleTool:
+		return []responses.ResponseInputItemUnionParam{
+			responses.ResponseInputItemParamOfFunctionCallOutput(msg.ToolCallID, msg.Text()),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("不支持的消息角色: %s", msg.Role)
+	}
+}
+
+// toResponsesContentList 将多模态内容片段转换为Responses API输入内容
+// toResponsesContentList converts multimodal content parts to Responses API input content
+func toResponsesContentList(parts []ContentPart) (responses.ResponseInputMessageContentListParam, error) {
+	result := make(responses.ResponseInputMessageContentListParam, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case PartText:
+			result = append(result, responses.ResponseInputContentParamOfInputText(part.Text))
+
+		case PartImageURL, PartImage:
+			imageURL := part.URL
+			if part.Type == PartImage {
+				// 内联图片以 data URL 形式发送
+				imageURL = part.dataURL()
+			}
+			result = append(result, responses.ResponseInputContentUnionParam{OfInputImage: &responses.ResponseInputImageParam{
+				Detail:   responses.ResponseInputImageDetailAuto,
+				ImageURL: openai.String(imageURL),
+			}})
+
+		case PartFile:
+			file := &responses.ResponseInputFileParam{}
+			if len(part.Data) > 0 {
+				file.FileData = openai.String(part.dataURL())
+			} else {
+				file.FileURL = openai.String(part.URL)
+			}
+			if part.FileName != "" {
+				file.Filename = openai.String(part.FileName)
+			}
+			result = append(result, responses.ResponseInputContentUnionParam{OfInputFile: file})
+
+		default:
+			return nil, fmt.Errorf("Responses API 不支持的内容片段类型: %s", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// toResponsesToolChoice 将Tool工具选择转换为Responses API格式
+// toResponsesToolChoice converts Union tool choice to Responses API format
+func toResponsesToolChoice(choice ToolChoiceOption) responses.ResponseNewParamsToolChoiceUnion {
+	switch choice.Type {
+	case ToolChoiceRequired:
+		return responses.ResponseNewParamsToolChoiceUnion{OfToolChoiceMode: param.NewOpt(responses.ToolChoiceOptionsRequired)}
+	case ToolChoiceNone:
+		return responses.ResponseNewParamsToolChoiceUnion{OfToolChoiceMode: param.NewOpt(responses.ToolChoiceOptionsNone)}
+	case ToolChoiceSpecific:
+		return responses.ResponseNewParamsToolChoiceUnion{OfFunctionTool: &responses.ToolChoiceFunctionParam{Name: choice.ToolName}}
+	default:
+		return responses.ResponseNewParamsToolChoiceUnion{OfToolChoiceMode: param.NewOpt(responses.ToolChoiceOptionsAuto)}
+	}
+}
+
+// responsesFailure 将 response.failed 响应中的错误转换为 *LLMError，响应未失败时返回 nil
+// responsesFailure converts the error of a response.failed response to an *LLMError, returning nil otherwise
+func responsesFailure(response *responses.Response) *LLMError {
+	if response.Status != responses.ResponseStatusFailed {
+		return nil
+	}
+	code, message := string(response.Error.Code), response.Error.Message
+	llmErr := NewLLMError(ProviderOpenAIResponses, ErrCodeAPI, "OpenAI Responses API响应失败", fmt.Errorf("%s: %s", code, message))
+	llmErr.Type = code
+	switch response.Error.Code {
+	case responses.ResponseErrorCodeServerError:
+		llmErr.Code = ErrCodeServerError
+	case responses.ResponseErrorCodeRateLimitExceeded:
+		llmErr.Code = ErrCodeRateLimited
+	case responses.ResponseErrorCodeVectorStoreTimeout:
+		llmErr.Code = ErrCodeTimeout
+	default:
+		// 其余错误码都是请求内容的问题（提示词或图片无效、违反内容策略等）
+		llmErr.Code = classifyAPIError(nil, http.StatusBadRequest, code, message)
+	}
+	return llmErr
+}
+
+// fromResponsesResponse 将Responses API响应转换为Union响应
+// 工具调用参数不是有效的 JSON 对象时同时返回输出和错误，该工具调用的参数为空
+// fromResponsesResponse converts Responses API response to Union response
+// When tool call arguments are not a valid JSON object, the output is returned together with an error
+// and the arguments of that tool call are left empty
+func fromResponsesResponse(response *responses.Response, startTime time.Time) (*Output, error) {
+	output := &Output{
+		StartAt:     startTime,
+		Content:     response.OutputText(),
+		Cost:        time.Since(startTime),
+		RawResponse: response,
+		// 返回响应ID，便于通过 PreviousResponseID 继续对话
+		Extra: map[string]any{"response_id": response.ID},
+	}
+
+	var (
+		thinking []string
+		errs     []error
+	)
+	for _, item := range response.Output {
+		switch item.Type {
+		case "reasoning":
+			for _, summary := range item.Summary {
+				thinking = append(thinking, summary.Text)
+			}
+		case "function_call":
+			args, err := parseToolArguments(item.Arguments)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("工具 %s（%s）: %w", item.Name, item.CallID, err))
+			}
+			output.ToolCalls = append(output.ToolCalls, ToolCall{
+				ID:        item.CallID,
+				Name:      item.Name,
+				Arguments: args,
+			})
+		}
+	}
+	output.Thinking = strings.Join(thinking, "\n\n")
+	output.FinishReason = fromResponsesStatus(response, len(output.ToolCalls) > 0)
+
+	usage := response.Usage
+	output.TokenUsage = TokenUsage{
+		InputTokens:    usage.InputTokens,
+		CachedTokens:   usage.InputTokensDetails.CachedTokens,
+		ThinkingTokens: usage.OutputTokensDetails.ReasoningTokens,
+		OutputTokens:   usage.OutputTokens,
+		TotalTokens:    usage.TotalTokens,
+	}
+
+	return output, errors.Join(errs...)
+}
+
+// fromResponsesStatus 将Responses API的状态转换为Union结束原因
+// fromResponsesStatus converts Responses API status to Union finish reason
+func fromResponsesStatus(response *responses.Response, hasToolCalls bool) string {
+	switch response.Status {
+	case responses.ResponseStatusIncomplete:
+		switch response.IncompleteDetails.Reason {
+		case "max_output_tokens":
+			return string(FinishReasonLength)
+		case "content_filter":
+			return string(FinishReasonContentFilter)
+		}
+		return response.IncompleteDetails.Reason
+	case responses.ResponseStatusFailed:
+		return string(FinishReasonError)
+	}
+	if hasToolCalls {
+		return string(FinishReasonToolCalls)
+	}
+	return string(FinishReasonStop)
+}
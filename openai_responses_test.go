@@ -0,0 +1,221 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/v3/responses"
+)
+
+func TestResponses_GenerateResponseNewParams(t *testing.T) {
+	client := CreateOpenAIResponses(APIKey("test"))
+	params, err := client.GenerateResponseNewParams(&Input{
+		Model: "o3-mini",
+		Messages: []Message{
+			SystemMessage("你是一个天气助手"),
+			UserMessage("查询北京的天气"),
+			AssistantMessageWithTools("我来查询", []ToolCall{
+				{ID: "call_1", Name: "query_weather", Arguments: map[string]any{"city": "beijing"}},
+			}),
+			ToolMessage("晴", "call_1"),
+		},
+		Tools:      Tools,
+		ToolChoice: &ToolChoiceOption{Type: ToolChoiceSpecific, ToolName: "query_weather"},
+	}, PreviousResponseID("resp_1"))
+	if err != nil {
+		t.Fatalf("GenerateResponseNewParams() error = %v", err)
+	}
+
+	if params.Instructions.Or("") != "你是一个天气助手" {
+		t.Errorf("Expected instructions, got %q", params.Instructions.Or(""))
+	}
+	if params.PreviousResponseID.Or("") != "resp_1" {
+		t.Errorf("Expected previous response id, got %q", params.PreviousResponseID.Or(""))
+	}
+	if params.Temperature.Valid() {
+		t.Error("Expected temperature to be omitted for reasoning models")
+	}
+	if params.Reasoning.Summary != "auto" {
+		t.Errorf("Expected reasoning summary auto, got %q", params.Reasoning.Summary)
+	}
+
+	// user, assistant text, function_call, function_call_output
+	items := params.Input.OfInputItemList
+	if len(items) != 4 {
+		t.Fatalf("Expected 4 input items, got %d", len(items))
+	}
+	if items[2].OfFunctionCall == nil || items[2].OfFunctionCall.CallID != "call_1" {
+		t.Errorf("Expected function_call item, got %+v", items[2])
+	}
+	if items[3].OfFunctionCallOutput == nil || items[3].OfFunctionCallOutput.CallID != "call_1" {
+		t.Errorf("Expected function_call_output item, got %+v", items[3])
+	}
+
+	if len(params.Tools) != len(Tools) {
+		t.Errorf("Expected %d tools, got %d", len(Tools), len(params.Tools))
+	}
+	if params.ToolChoice.OfFunctionTool == nil || params.ToolChoice.OfFunctionTool.Name != "query_weather" {
+		t.Errorf("Expected function tool choice, got %+v", params.ToolChoice)
+	}
+}
+
+func TestResponses_MaxOutputTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		model string
+		opts  []Option
+		want  int64
+	}{
+		{"目录上限", "gpt-4o", nil, 16384},
+		{"带日期的版本", "gpt-4.1-2025-04-14", nil, 32768},
+		{"未知模型不发送", "my-model", nil, 0},
+		{"显式设置", "gpt-4o", []Option{MaxTokens(2048)}, 2048},
+		{"按模型上限截断", "gpt-4o", []Option{MaxTokens(defaultMaxTokens)}, 16384},
+		{"未知模型显式设置", "my-model", []Option{MaxTokens(100000)}, 100000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := CreateOpenAIResponses(APIKey("test")).GenerateResponseNewParams(&Input{Model: tt.model}, tt.opts...)
+			if err != nil {
+				t.Fatalf("GenerateResponseNewParams() error = %v", err)
+			}
+			if got := params.MaxOutputTokens.Or(0); got != tt.want || params.MaxOutputTokens.Valid() != (tt.want > 0) {
+				t.Errorf("Expected max output tokens %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResponses_Provider(t *testing.T) {
+	llm, err := New(context.Background(), Config{Provider: ProviderOpenAIResponses, APIKey: "test"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if info := providerInfoOf(llm); info.Type != ProviderOpenAIResponses || genAISystem(info.Type).Value.AsString() != "openai" {
+		t.Errorf("Expected provider %s, got %+v", ProviderOpenAIResponses, info)
+	}
+
+	server := newErrorServer(t, http.StatusTooManyRequests, nil, `{"error": {"message": "限流", "type": "rate_limit_exceeded"}}`)
+	_, err = CreateOpenAIResponses(URL(server.URL), APIKey("test")).Completion(context.Background(), &Input{Model: "gpt-4o"})
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Provider != ProviderOpenAIResponses {
+		t.Errorf("Expected an error from %s, got %v", ProviderOpenAIResponses, err)
+	}
+}
+
+func TestResponses_fromResponsesResponse(t *testing.T) {
+	raw := `{
+		"id": "resp_1",
+		"object": "response",
+		"status": "completed",
+		"model": "o3-mini",
+		"output": [
+			{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "需要先查询天气"}]},
+			{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "query_weather", "arguments": "{\"city\":\"beijing\"}"}
+		],
+		"usage": {"input_tokens": 10, "output_tokens": 30, "output_tokens_details": {"reasoning_tokens": 20}, "total_tokens": 40}
+	}`
+	var response responses.Response
+	if err := json.Unmarshal([]byte(raw), &response); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	output, err := fromResponsesResponse(&response, time.Now())
+	if err != nil {
+		t.Fatalf("fromResponsesResponse() error = %v", err)
+	}
+	if output.Thinking != "需要先查询天气" {
+		t.Errorf("Expected thinking from reasoning summary, got %q", output.Thinking)
+	}
+	if output.FinishReason != string(FinishReasonToolCalls) {
+		t.Errorf("Expected finish reason tool_calls, got %s", output.FinishReason)
+	}
+	if len(output.ToolCalls) != 1 || output.ToolCalls[0].ID != "call_1" || output.ToolCalls[0].Arguments["city"] != "beijing" {
+		t.Errorf("Expected one query_weather tool call, got %+v", output.ToolCalls)
+	}
+	if output.TokenUsage.ThinkingTokens != 20 || output.TokenUsage.TotalTokens != 40 {
+		t.Errorf("Unexpected token usage: %+v", output.TokenUsage)
+	}
+	if output.Extra["response_id"] != "resp_1" {
+		t.Errorf("Expected response id in extra, got %v", output.Extra["response_id"])
+	}
+}
+
+func TestResponses_Failed(t *testing.T) {
+	const failed = `{"id": "resp_1", "object": "response", "status": "failed", "model": "o3-mini", "output": [], ` +
+		`"error": {"code": "rate_limit_exceeded", "message": "Rate limit reached"}}`
+	input := &Input{Model: "o3-mini", Messages: []Message{UserMessage("hi")}}
+
+	server := newErrorServer(t, http.StatusOK, nil, failed)
+	output, err := CreateOpenAIResponses(URL(server.URL), APIKey("test")).Completion(context.Background(), input)
+	var llmErr *LLMError
+	if output != nil || !errors.As(err, &llmErr) {
+		t.Fatalf("Expected an *LLMError for a failed response, got %+v, %v", output, err)
+	}
+	if llmErr.Code != ErrCodeRateLimited || llmErr.Type != "rate_limit_exceeded" || !strings.Contains(err.Error(), "Rate limit reached") {
+		t.Errorf("Unexpected error: %+v", llmErr)
+	}
+
+	stream := newSSEServer(t,
+		`data: {"type":"response.output_text.delta","output_index":0,"delta":"你好"}`+"\n\n",
+		`data: {"type":"response.failed","response":`+failed+`}`+"\n\n",
+	)
+	output, err = CreateOpenAIResponses(URL(stream.URL), APIKey("test")).CompletionStreamEvents(context.Background(), input, func(StreamEvent) {})
+	var incomplete *IncompleteError
+	if !errors.As(err, &incomplete) || !errors.Is(err, ErrRateLimited) || output == nil || output.Content != "你好" {
+		t.Errorf("Expected the partial output with a rate limit error, got %+v, %v", output, err)
+	}
+}
+
+func TestResponses_InvalidToolArguments(t *testing.T) {
+	const completed = `{"id": "resp_1", "object": "response", "status": "completed", "model": "o3-mini", ` +
+		`"output": [{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "query_weather", "arguments": "{\"city\":"}], ` +
+		`"usage": {"input_tokens": 10, "output_tokens": 5, "total_tokens": 15}}`
+	input := &Input{Model: "o3-mini", Messages: []Message{UserMessage("hi")}}
+
+	server := newErrorServer(t, http.StatusOK, nil, completed)
+	_, err := CreateOpenAIResponses(URL(server.URL), APIKey("test")).Completion(context.Background(), input)
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "INVALID_TOOL_ARGUMENTS" {
+		t.Fatalf("Expected INVALID_TOOL_ARGUMENTS error, got %v", err)
+	}
+
+	stream := newSSEServer(t, `data: {"type":"response.completed","response":`+completed+`}`+"\n\n")
+	output, err := CreateOpenAIResponses(URL(stream.URL), APIKey("test")).CompletionStreamEvents(context.Background(), input, func(StreamEvent) {})
+	if !errors.As(err, &llmErr) || llmErr.Code != "INVALID_TOOL_ARGUMENTS" {
+		t.Fatalf("Expected INVALID_TOOL_ARGUMENTS error, got %v", err)
+	}
+	if output == nil || output.TokenUsage.TotalTokens != 15 || len(output.ToolCalls) != 1 {
+		t.Errorf("Expected the output to be returned with the error, got %+v", output)
+	}
+}
+
+func TestResponses_MultimodalMessage(t *testing.T) {
+	client := CreateOpenAIResponses(APIKey("test"))
+	params, err := client.GenerateResponseNewParams(&Input{
+		Model: "gpt-4o",
+		Messages: []Message{UserMessageWithParts(
+			TextPart("总结这份文档"),
+			ImagePart([]byte("png"), "image/png"),
+			FileURLPart("https://example.com/a.pdf", "application/pdf"),
+		)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateResponseNewParams() error = %v", err)
+	}
+	content := params.Input.OfInputItemList[0].OfMessage.Content.OfInputItemContentList
+	if len(content) != 3 {
+		t.Fatalf("Expected 3 content items, got %d", len(content))
+	}
+	if content[1].OfInputImage.ImageURL.Or("") != "data:image/png;base64,cG5n" {
+		t.Errorf("Unexpected inline image: %s", content[1].OfInputImage.ImageURL.Or(""))
+	}
+	if content[2].OfInputFile.FileURL.Or("") != "https://example.com/a.pdf" {
+		t.Errorf("Unexpected file url: %s", content[2].OfInputFile.FileURL.Or(""))
+	}
+}
@@ -2,11 +2,18 @@ package OpenLLM
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/golang-io/requests"
+	"github.com/openai/openai-go/v3"
 )
 
 var input = &Input{
@@ -73,3 +80,189 @@ func TestVenusCompletion(t *testing.T) {
 	})
 	log.Printf("%#v, err=%v", output, err)
 }
+
+func TestOpenAI_MultimodalMessage(t *testing.T) {
+	client := CreateOpenAI(APIKey("test"))
+	params, err := client.GenerateOpenAIChatCompletionNewParams(&Input{
+		Model: "gpt-4o",
+		Messages: []Message{UserMessageWithParts(
+			TextPart("描述这张图片"),
+			ImageURLPart("https://example.com/cat.png"),
+			ImagePart([]byte("png"), "image/png"),
+			AudioPart([]byte("wav"), "audio/wav"),
+			FilePart("report.pdf", []byte("pdf"), "application/pdf"),
+		)},
+	})
+	if err != nil {
+		t.Fatalf("GenerateOpenAIChatCompletionNewParams() error = %v", err)
+	}
+
+	parts := params.Messages[0].OfUser.Content.OfArrayOfContentParts
+	if len(parts) != 5 {
+		t.Fatalf("Expected 5 content parts, got %d", len(parts))
+	}
+	if parts[1].OfImageURL.ImageURL.URL != "https://example.com/cat.png" {
+		t.Errorf("Unexpected image url: %s", parts[1].OfImageURL.ImageURL.URL)
+	}
+	if parts[2].OfImageURL.ImageURL.URL != "data:image/png;base64,cG5n" {
+		t.Errorf("Unexpected inline image: %s", parts[2].OfImageURL.ImageURL.URL)
+	}
+	if parts[3].OfInputAudio.InputAudio.Format != "wav" {
+		t.Errorf("Unexpected audio format: %s", parts[3].OfInputAudio.InputAudio.Format)
+	}
+	if parts[4].OfFile.File.Filename.Or("") != "report.pdf" {
+		t.Errorf("Unexpected file name: %s", parts[4].OfFile.File.Filename.Or(""))
+	}
+
+	if _, err := client.GenerateOpenAIChatCompletionNewParams(&Input{
+		Messages: []Message{UserMessageWithParts(FileURLPart("https://example.com/a.pdf", "application/pdf"))},
+	}); err == nil {
+		t.Error("Expected error for file url in chat completions")
+	}
+}
+
+func TestMessage_Text(t *testing.T) {
+	msg := UserMessageWithImage("这是什么?", "https://example.com/cat.png")
+	if msg.Text() != "这是什么?" || msg.Content != "这是什么?" {
+		t.Errorf("Unexpected text: %q / %q", msg.Text(), msg.Content)
+	}
+	if len(msg.Parts) != 2 || msg.Parts[1].Type != PartImageURL {
+		t.Errorf("Unexpected parts: %+v", msg.Parts)
+	}
+	if UserMessage("hi").Text() != "hi" {
+		t.Error("Expected plain content to be returned")
+	}
+}
+
+func TestOpenAI_ReasoningContent(t *testing.T) {
+	raw := `{
+		"id": "1", "object": "chat.completion", "created": 1, "model": "kimi-k2-thinking",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "晴", "reasoning_content": "先查询天气"}}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 30, "total_tokens": 40, "completion_tokens_details": {"reasoning_tokens": 20}}
+	}`
+	var completion openai.ChatCompletion
+	if err := json.Unmarshal([]byte(raw), &completion); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	output, err := fromOpenAIResponse(&completion, 0)
+	if err != nil {
+		t.Fatalf("fromOpenAIResponse() error = %v", err)
+	}
+	if output.Thinking != "先查询天气" || output.Content != "晴" {
+		t.Errorf("Expected reasoning content as thinking, got %q / %q", output.Thinking, output.Content)
+	}
+	if output.TokenUsage.ThinkingTokens != 20 {
+		t.Errorf("Expected 20 thinking tokens, got %d", output.TokenUsage.ThinkingTokens)
+	}
+
+	server := newSSEServer(t,
+		openAIChunk(`{"role":"assistant","reasoning_content":"先查询"}`, ""),
+		openAIChunk(`{"reasoning":"天气"}`, ""),
+		openAIChunk(`{"content":"晴"}`, `,"finish_reason":"stop"`),
+		"data: [DONE]\n\n",
+	)
+	var thinking strings.Builder
+	output, err = CreateOpenAI(URL(server.URL), APIKey("test")).CompletionStreamEvents(context.Background(), &Input{Model: "m"}, func(event StreamEvent) {
+		if event.Type == StreamEventThinking {
+			thinking.WriteString(event.Content)
+		}
+	})
+	if err != nil {
+		t.Fatalf("CompletionStreamEvents() error = %v", err)
+	}
+	if thinking.String() != "先查询天气" || output.Thinking != "先查询天气" || output.Content != "晴" {
+		t.Errorf("Expected streamed reasoning content as thinking, got %q / %q / %q", thinking.String(), output.Thinking, output.Content)
+	}
+}
+
+func TestOpenAI_StreamToolCallOrder(t *testing.T) {
+	// 并行工具调用的增量交错到达，且部分提供商会在后续增量中重复发送ID和名称
+	server := newSSEServer(t,
+		openAIChunk(`{"tool_calls":[{"index":2,"id":"call_c","type":"function","function":{"name":"c","arguments":"{"}}]}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"a","arguments":"{\"n\":"}}]}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":1,"id":"call_b","type":"function","function":{"name":"b","arguments":"{}"}}]}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"a","arguments":"1}"}}]}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":2,"function":{"arguments":"}"}}]}`, `,"finish_reason":"tool_calls"`),
+		"data: [DONE]\n\n",
+	)
+	client := CreateOpenAI(URL(server.URL), APIKey("test"))
+
+	for i := 0; i < 5; i++ {
+		output, err := client.CompletionStreamEvents(context.Background(), &Input{Model: "m"}, nil)
+		if err != nil {
+			t.Fatalf("CompletionStreamEvents() error = %v", err)
+		}
+		var got []string
+		for _, call := range output.ToolCalls {
+			got = append(got, call.ID+":"+call.Name)
+		}
+		if want := "call_a:a,call_b:b,call_c:c"; strings.Join(got, ",") != want {
+			t.Fatalf("Expected tool calls %s, got %s", want, strings.Join(got, ","))
+		}
+		if output.ToolCalls[0].Arguments["n"] != float64(1) {
+			t.Errorf("Expected arguments {\"n\":1}, got %v", output.ToolCalls[0].Arguments)
+		}
+	}
+}
+
+func TestOpenAI_InvalidToolArguments(t *testing.T) {
+	server := newSSEServer(t,
+		openAIChunk(`{"role":"assistant","content":"查询中"}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"query_weather","arguments":"{\"city\":"}}]}`, `,"finish_reason":"tool_calls"`),
+		`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`+"\n\n",
+		"data: [DONE]\n\n",
+	)
+	output, err := CreateOpenAI(URL(server.URL), APIKey("test")).CompletionStreamEvents(context.Background(), &Input{Model: "m"}, nil)
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "INVALID_TOOL_ARGUMENTS" {
+		t.Fatalf("Expected INVALID_TOOL_ARGUMENTS error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "call_1") {
+		t.Errorf("Expected error to mention the tool call, got %v", err)
+	}
+	// 流式内容和用量随错误一起返回
+	if output == nil || output.Content != "查询中" || output.TokenUsage.TotalTokens != 15 || len(output.ToolCalls) != 1 {
+		t.Errorf("Expected the streamed output to be returned with the error, got %+v", output)
+	}
+
+	// 空参数视为无参数
+	args, err := parseToolArguments("")
+	if err != nil || len(args) != 0 {
+		t.Errorf("Expected empty arguments, got %v, %v", args, err)
+	}
+}
+
+func TestOpenAI_StreamUsage(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, openAIChunk(`{"role":"assistant","content":"你好"}`, `,"finish_reason":"stop"`))
+		fmt.Fprint(w, `data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":100,"completion_tokens":30,"total_tokens":130,`+
+			`"prompt_tokens_details":{"cached_tokens":80},"completion_tokens_details":{"reasoning_tokens":20}}}`+"\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	output, err := CreateOpenAI(URL(server.URL), APIKey("test")).CompletionStream(context.Background(), &Input{Model: "m"}, nil)
+	if err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	want := TokenUsage{InputTokens: 100, CachedTokens: 80, ThinkingTokens: 20, OutputTokens: 30, TotalTokens: 130}
+	if output.TokenUsage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, output.TokenUsage)
+	}
+	if streamOptions, _ := bodies[0]["stream_options"].(map[string]any); streamOptions["include_usage"] != true {
+		t.Errorf("Expected stream_options.include_usage by default, got %v", bodies[0]["stream_options"])
+	}
+
+	// 关闭后不再发送 stream_options
+	if _, err := CreateAzure(URL(server.URL), APIKey("test"), DisableStreamUsage()).CompletionStream(context.Background(), &Input{Model: "m"}, nil); err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	if _, ok := bodies[1]["stream_options"]; ok {
+		t.Errorf("Expected no stream_options when disabled, got %v", bodies[1]["stream_options"])
+	}
+}
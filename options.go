@@ -1,6 +1,7 @@
 package OpenLLM
 
 import (
+	"log/slog"
 	"os"
 
 	"github.com/golang-io/requests"
@@ -13,17 +14,33 @@ import (
 // Options LLM 客户端配置选项
 // Options defines configuration options for LLM clients
 type Options struct {
-	Provider          string            `json:"provider,omitempty"`            // 提供商类型 / Provider type
-	URL               string            `json:"url,omitempty"`                 // API基础URL / API base URL
-	APIKey            string            `json:"api_key,omitempty"`             // API密钥 / API key
-	Temperature       float64           `json:"temperature,omitempty"`         // 默认温度 / Default temperature
-	MaxTokens         int64             `json:"max_tokens,omitempty"`          // 默认最大token数 / Default max tokens
-	TopP              float64           `json:"top_p,omitempty"`               // 默认TopP / Default top-p
-	JSONSet           map[string]any    `json:"json_set,omitempty"`            // 扩展配置（提供商特定）/ Extended config (provider-specific)
-	Seed              int64             `json:"seed,omitempty"`                // 随机种子 / Random seed
-	HTTPClientOptions []requests.Option `json:"http_client_options,omitempty"` // HTTP客户端配置 / HTTP client options
+	Provider           string            `json:"provider,omitempty"`             // 提供商类型 / Provider type
+	URL                string            `json:"url,omitempty"`                  // API基础URL / API base URL
+	APIKey             string            `json:"api_key,omitempty"`              // API密钥 / API key
+	Model              string            `json:"model,omitempty"`                // 默认模型（Input.Model为空时使用）/ Default model (used when Input.Model is empty)
+	Temperature        float64           `json:"temperature,omitempty"`          // 默认温度 / Default temperature
+	MaxTokens          int64             `json:"max_tokens,omitempty"`           // 默认最大token数 / Default max tokens
+	TopP               float64           `json:"top_p,omitempty"`                // 默认TopP / Default top-p
+	JSONSet            map[string]any    `json:"json_set,omitempty"`             // 扩展配置（提供商特定）/ Extended config (provider-specific)
+	Seed               int64             `json:"seed,omitempty"`                 // 随机种子 / Random seed
+	HTTPClientOptions  []requests.Option `json:"http_client_options,omitempty"`  // HTTP客户端配置 / HTTP client options
+	PreviousResponseID string            `json:"previous_response_id,omitempty"` // 上一次响应ID（仅Responses API）/ Previous response ID (Responses API only)
+	DisableStreamUsage bool              `json:"disable_stream_usage,omitempty"` // 流式请求不发送 stream_options.include_usage / Do not send stream_options.include_usage on streaming requests
+
+	StructuredOutputRetries int `json:"structured_output_retries,omitempty"` // 结构化输出解析失败时的重试次数（仅CompletionAs）/ Retries on structured output failures (CompletionAs only)
+
+	Logger     *slog.Logger `json:"-"`                     // 日志记录器，设置后记录 HTTP 请求摘要 / Logger; HTTP request summaries are logged when set
+	LogLevel   slog.Level   `json:"log_level,omitempty"`   // 请求摘要的日志级别，默认 Debug / Level of request summaries, Debug by default
+	LogContent bool         `json:"log_content,omitempty"` // 是否记录请求头、请求体和响应体 / Whether headers and bodies are logged
+	Redactors  []Redactor   `json:"-"`                     // 日志内容的脱敏函数 / Redactors applied to logged content
+
+	maxTokensSet bool // 是否通过 MaxTokens 显式设置 / Whether MaxTokens was set explicitly
 }
 
+// defaultMaxTokens 默认的最大输出 token 数
+// defaultMaxTokens is the default maximum number of output tokens
+const defaultMaxTokens = 128 * 1000
+
 // Option 配置函数类型
 // Option is a function type for configuring Options
 type Option func(*Options)
@@ -35,9 +52,10 @@ func newOptions(opts []Option, extends ...Option) *Options {
 		URL:         os.Getenv("OpenLLM_BASE_URL"),
 		APIKey:      os.Getenv("OpenLLM_API_KEY"),
 		Temperature: 0.2,
-		MaxTokens:   128 * 1000,
+		MaxTokens:   defaultMaxTokens,
 		Seed:        88,
 		JSONSet:     make(map[string]any),
+		LogLevel:    slog.LevelDebug,
 	}
 	for _, o := range opts {
 		o(options)
@@ -48,6 +66,30 @@ func newOptions(opts []Option, extends ...Option) *Options {
 	return options
 }
 
+// maxTokens 返回显式设置的最大输出 token 数，未通过 MaxTokens 设置或不大于 0 时返回 false
+// maxTokens returns the max output tokens set explicitly, reporting false when MaxTokens was not used or is not positive
+func (o *Options) maxTokens() (int64, bool) {
+	return o.MaxTokens, o.maxTokensSet && o.MaxTokens > 0
+}
+
+// capMaxTokens 将最大输出 token 数截断到模型目录中的上限，模型不在目录中时原样返回
+// capMaxTokens caps the max output tokens at the limit from the model catalog, returning it as is for unknown models
+func capMaxTokens(maxTokens int64, model string) int64 {
+	if info, ok := LookupModel(model); ok && info.MaxOutputTokens > 0 {
+		return min(maxTokens, info.MaxOutputTokens)
+	}
+	return maxTokens
+}
+
+// modelName 返回请求使用的模型：优先使用 Input.Model，否则使用默认模型
+// modelName returns the model to request: Input.Model first, then the default model
+func (o *Options) modelName(input *Input) string {
+	if input.Model != "" {
+		return input.Model
+	}
+	return o.Model
+}
+
 // ============================================================================
 // 配置函数 / Configuration Functions
 // ============================================================================
@@ -68,6 +110,14 @@ func APIKey(apiKey string) Option {
 	}
 }
 
+// Model 设置默认模型，Input.Model 为空时使用
+// Model sets the default model, used when Input.Model is empty
+func Model(model string) Option {
+	return func(options *Options) {
+		options.Model = model
+	}
+}
+
 // Seed 设置随机种子（用于结果可复现）
 // Seed sets the random seed (for reproducible results)
 func Seed(seed int64) Option {
@@ -89,6 +139,7 @@ func Temperature(temperature float64) Option {
 func MaxTokens(maxTokens int64) Option {
 	return func(options *Options) {
 		options.MaxTokens = maxTokens
+		options.maxTokensSet = true
 	}
 }
 
@@ -115,3 +166,67 @@ func HTTPClientOptions(httpClientOptions ...requests.Option) Option {
 		options.HTTPClientOptions = append(options.HTTPClientOptions, httpClientOptions...)
 	}
 }
+
+// PreviousResponseID 设置上一次响应的 ID，用于 Responses API 的多轮对话链（仅需发送新增消息）
+// PreviousResponseID sets the previous response ID to chain Responses API turns (only new messages need to be sent)
+func PreviousResponseID(id string) Option {
+	return func(options *Options) {
+		options.PreviousResponseID = id
+	}
+}
+
+// DisableStreamUsage 流式请求不再设置 stream_options.include_usage（默认开启），用于不支持该参数的兼容服务
+// 关闭后流式调用只有在服务端主动返回时才有 token 使用量
+// DisableStreamUsage stops setting stream_options.include_usage on streaming requests (enabled by default),
+// for compatible backends that reject the parameter
+// Streamed calls then only report token usage when the server sends it anyway
+func DisableStreamUsage() Option {
+	return func(options *Options) {
+		options.DisableStreamUsage = true
+	}
+}
+
+// StructuredOutputRetries 设置 CompletionAs 解析或校验失败时，携带错误信息重新请求模型的次数
+// StructuredOutputRetries sets how many times CompletionAs re-prompts the model with the error when decoding or validation fails
+func StructuredOutputRetries(retries int) Option {
+	return func(options *Options) {
+		options.StructuredOutputRetries = retries
+	}
+}
+
+// Logger 设置日志记录器，设置后以 LogLevel 记录每个 HTTP 请求和响应的摘要（方法、URL、状态码、请求ID、耗时）
+// API 密钥和认证请求头总是被脱敏；未设置时提供商的警告写入 slog.Default()
+// Logger sets the logger; once set, a summary of every HTTP request and response (method, URL, status, request ID
+// and duration) is logged at LogLevel
+// API keys and authentication headers are always redacted; without a logger, provider warnings go to slog.Default()
+func Logger(logger *slog.Logger) Option {
+	return func(options *Options) {
+		options.Logger = logger
+	}
+}
+
+// LogLevel 设置请求摘要的日志级别（默认 Debug），失败的请求至少以 Warn 级别记录
+// LogLevel sets the level of request summaries (Debug by default); failed requests are logged at Warn or above
+func LogLevel(level slog.Level) Option {
+	return func(options *Options) {
+		options.LogLevel = level
+	}
+}
+
+// LogContent 同时记录请求头、请求体和非流式响应体，其中的提示词和回复可能包含个人信息，建议配合 Redact 使用
+// LogContent also logs headers, request bodies and non-streaming response bodies; the prompts and completions
+// they contain may include personal information, so combining it with Redact is recommended
+func LogContent() Option {
+	return func(options *Options) {
+		options.LogContent = true
+	}
+}
+
+// Redact 添加日志内容的脱敏函数（如 RedactEmails、RedactPhoneNumbers、RedactIDNumbers），按添加顺序执行
+// Redact adds redactors for logged content (such as RedactEmails, RedactPhoneNumbers or RedactIDNumbers),
+// applied in the order they were added
+func Redact(redactors ...Redactor) Option {
+	return func(options *Options) {
+		options.Redactors = append(options.Redactors, redactors...)
+	}
+}
@@ -0,0 +1,153 @@
+package OpenLLM
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
+// ============================================================================
+// 不完整 JSON 解析 / Partial JSON Parsing
+// ============================================================================
+
+// parsePartialJSON 尽力解析流式生成中的不完整 JSON 对象，无法解析时返回 nil
+// 未结束的字符串值会被截断保留，未完成的键以及后面没有分隔符的数字和字面量会被丢弃
+// parsePartialJSON parses an incomplete JSON object that is still being streamed, returning nil when it cannot be parsed
+// Unterminated string values are kept as truncated, while unfinished keys and numbers or literals not yet followed
+// by a delimiter are dropped
+func parsePartialJSON(s string) map[string]any {
+	completed, ok := completePartialJSON(s)
+	if !ok {
+		return nil
+	}
+	var value map[string]any
+	if err := json.Unmarshal([]byte(completed), &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// completePartialJSON 将不完整的 JSON 截断到最后一个完整的值并补全括号
+// completePartialJSON truncates the incomplete JSON to the last complete value and closes the open brackets
+func completePartialJSON(s string) (string, bool) {
+	var (
+		stack    []byte // 待补全的右括号 / Pending closing brackets
+		cut      = -1   // 最后一个完整值的结束位置 / End of the last complete value
+		cutStack []byte
+		key      bool // 下一个字符串是否为对象的键 / Whether the next string is an object key
+	)
+	checkpoint := func(i int) {
+		cut, cutStack = i, slices.Clone(stack)
+	}
+
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case '{', '[':
+			if c == '{' {
+				stack = append(stack, '}')
+			} else {
+				stack = append(stack, ']')
+			}
+			key = c == '{'
+			i++
+			checkpoint(i)
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return "", false
+			}
+			stack = stack[:len(stack)-1]
+			key = false
+			i++
+			checkpoint(i)
+		case ',':
+			key = len(stack) > 0 && stack[len(stack)-1] == '}'
+			i++
+		case ':':
+			key = false
+			i++
+		case ' ', '\t', '\n', '\r':
+			i++
+		case '"':
+			end := stringEnd(s, i+1)
+			if end < 0 {
+				if key {
+					// 未完成的键没有对应的值，丢弃 / An unfinished key has no value yet, drop it
+					return closePartialJSON(s, cut, cutStack)
+				}
+				// 保留未结束的字符串值 / Keep the unterminated string value
+				return s[:i] + trimPartialEscape(s[i:]) + `"` + closers(stack), true
+			}
+			i = end
+			if !key {
+				checkpoint(i)
+			}
+		default:
+			// 数字或字面量 / Number or literal
+			j := i
+			for j < len(s) && !strings.ContainsRune(",]}: \t\n\r", rune(s[j])) {
+				j++
+			}
+			// 没有分隔符结尾的数字或字面量可能还会继续增长（如 12 → 123），丢弃
+			if j == len(s) {
+				return closePartialJSON(s, cut, cutStack)
+			}
+			i = j
+			checkpoint(i)
+		}
+	}
+	return closePartialJSON(s, cut, cutStack)
+}
+
+// closePartialJSON 截断到 cut 并补全 stack 中的括号
+// closePartialJSON truncates s at cut and appends the brackets in stack
+func closePartialJSON(s string, cut int, stack []byte) (string, bool) {
+	if cut < 0 {
+		return "", false
+	}
+	return s[:cut] + closers(stack), true
+}
+
+// closers 按嵌套顺序返回需要补全的右括号
+// closers returns the closing brackets in nesting order
+func closers(stack []byte) string {
+	closing := slices.Clone(stack)
+	slices.Reverse(closing)
+	return string(closing)
+}
+
+// stringEnd 返回从 start 开始的字符串结束引号之后的位置，字符串未结束时返回 -1
+// stringEnd returns the position after the closing quote of the string starting at start, or -1 if it is unterminated
+func stringEnd(s string, start int) int {
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// trimPartialEscape 去掉未结束字符串末尾不完整的转义序列
+// trimPartialEscape removes an incomplete escape sequence from the end of an unterminated string
+func trimPartialEscape(s string) string {
+	// 不完整的 \uXXXX / Incomplete \uXXXX
+	if k := strings.LastIndex(s, `\u`); k >= 0 && len(s)-k < 6 && escapedAt(s, k) {
+		return s[:k]
+	}
+	if strings.HasSuffix(s, `\`) && escapedAt(s, len(s)-1) {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// escapedAt 判断位置 i 的反斜杠是否开始一个转义序列（前面有偶数个反斜杠）
+// escapedAt reports whether the backslash at i starts an escape sequence (preceded by an even number of backslashes)
+func escapedAt(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 0
+}
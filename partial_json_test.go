@@ -0,0 +1,38 @@
+package OpenLLM
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePartialJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  map[string]any
+	}{
+		{``, nil},
+		{`{`, map[string]any{}},
+		{`{"pa`, map[string]any{}},
+		{`{"path"`, map[string]any{}},
+		{`{"path":`, map[string]any{}},
+		{`{"path": "/usr/lo`, map[string]any{"path": "/usr/lo"}},
+		{`{"path": "/usr/local", "re`, map[string]any{"path": "/usr/local"}},
+		{`{"sql": "SELECT \"na`, map[string]any{"sql": `SELECT "na`}},
+		{`{"sql": "a\`, map[string]any{"sql": "a"}},
+		{`{"sql": "a\u00`, map[string]any{"sql": "a"}},
+		{`{"limit": 12`, map[string]any{}},
+		{`{"limit": 12 `, map[string]any{"limit": float64(12)}},
+		{`{"limit": 12, "ok": true`, map[string]any{"limit": float64(12)}},
+		{`{"limit": -`, map[string]any{}},
+		{`{"ok": tr`, map[string]any{}},
+		{`{"ok": true, "tags": ["a", "b`, map[string]any{"ok": true, "tags": []any{"a", "b"}}},
+		{`{"filter": {"city": "北京"}, "days": [1, 2`, map[string]any{"filter": map[string]any{"city": "北京"}, "days": []any{float64(1)}}},
+		{`{"city": "北京"}`, map[string]any{"city": "北京"}},
+		{`[1, 2`, nil},
+	}
+	for _, tt := range tests {
+		if got := parsePartialJSON(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePartialJSON(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ============================================================================
+// 结构化输出 / Structured Output
+// ============================================================================
+
+// defaultResponseFormatName 未指定名称时使用的格式名称
+// defaultResponseFormatName is used when the response format has no name
+const defaultResponseFormatName = "response"
+
+// responseFormatNamePattern 格式名称的合法字符（与 OpenAI 的限制一致）
+// responseFormatNamePattern matches valid format names (same restriction as OpenAI)
+var responseFormatNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// name 返回格式名称，未设置时返回默认名称
+// name returns the format name, falling back to the default
+func (f *ResponseFormat) name() string {
+	if f.Name == "" {
+		return defaultResponseFormatName
+	}
+	return f.Name
+}
+
+// Validate 验证结构化输出格式定义是否有效
+// Validate checks if the response format definition is valid
+func (f *ResponseFormat) Validate() error {
+	if !responseFormatNamePattern.MatchString(f.name()) {
+		return fmt.Errorf("格式名称 %s 只能包含字母、数字、下划线和中划线，且不超过64个字符", f.Name)
+	}
+	if f.Schema == nil {
+		return fmt.Errorf("格式Schema不能为空")
+	}
+	if f.Schema.Type != "object" {
+		return fmt.Errorf("格式Schema类型必须是object，当前为: %s", f.Schema.Type)
+	}
+	return f.Schema.Validate()
+}
+
+// openAISchema 返回OpenAI使用的Schema以及是否启用严格模式
+// Schema 含 map 类型时严格模式会丢失数据，此时退回非严格模式，由 ValidateContent 在本地校验
+// openAISchema returns the schema sent to OpenAI and whether strict mode is enabled
+// Strict mode would lose the data of map types, so such schemas fall back to non-strict mode and are
+// validated locally by ValidateContent
+func (f *ResponseFormat) openAISchema() (map[string]any, bool) {
+	if f.Schema.SupportsOpenAIStrict() {
+		return f.Schema.ToOpenAIStrictSchema(), true
+	}
+	return f.Schema.ToOpenAIParameters(), false
+}
+
+// ValidateContent 验证模型返回的内容是否为符合Schema的JSON，不符合时返回 *SchemaValidationError
+// ValidateContent checks that the content is JSON conforming to the schema, returning *SchemaValidationError otherwise
+func (f *ResponseFormat) ValidateContent(content string) error {
+	var value any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &value); err != nil {
+		return &SchemaValidationError{Path: "$", Message: "不是有效的JSON: " + err.Error(), Content: content}
+	}
+	if err := f.Schema.ValidateValue(value); err != nil {
+		var schemaErr *SchemaValidationError
+		if errors.As(err, &schemaErr) {
+			schemaErr.Content = content
+		}
+		return err
+	}
+	return nil
+}
+
+// validateStructuredOutput 校验结构化输出，校验失败时同时返回输出和错误，便于调用方查看原始内容
+// 模型选择调用工具时不做校验
+// validateStructuredOutput validates the structured output, returning both output and error on failure
+// so callers can inspect the raw content; outputs that call tools are not validated
+func validateStructuredOutput(provider ProviderType, input *Input, output *Output) (*Output, error) {
+	if input.ResponseFormat == nil || len(output.ToolCalls) > 0 {
+		return output, nil
+	}
+	if err := input.ResponseFormat.ValidateContent(output.Content); err != nil {
+		return output, NewLLMError(provider, "SCHEMA_VALIDATION_ERROR", "响应不符合JSON Schema", err)
+	}
+	return output, nil
+}
+
+// CompletionAs 请求结构化输出并解码为 T
+// 根据 T 通过反射生成 JSON Schema，解码或校验失败时按 StructuredOutputRetries 携带错误信息重新请求模型
+// CompletionAs requests structured output and decodes it into T
+// The JSON schema is derived from T by reflection; on decode or validation failures the model is
+// re-prompted with the error up to StructuredOutputRetries times
+// 设置了 Input.Tools 且模型选择调用工具时，返回输出和 TOOL_CALLS 错误，调用方执行工具后再次调用
+// When Input.Tools is set and the model calls tools, the output is returned with a TOOL_CALLS error so that
+// the caller can run the tools and call again
+func CompletionAs[T any](ctx context.Context, llm LLM, input *Input, opts ...Option) (T, *Output, error) {
+	var result T
+	provider := providerOf(llm)
+
+	schema, err := schemaForType(reflect.TypeFor[T]())
+	if err != nil {
+		return result, nil, NewLLMError(provider, "CONVERT_ERROR", "生成JSON Schema失败", err)
+	}
+
+	// 复制请求，避免修改调用方的 Input
+	request := *input
+	request.Messages = slices.Clone(input.Messages)
+	format := &ResponseFormat{Schema: schema}
+	if input.ResponseFormat != nil {
+		format.Name = input.ResponseFormat.Name
+		format.Description = input.ResponseFormat.Description
+	} else if name := reflect.TypeFor[T]().Name(); responseFormatNamePattern.MatchString(name) {
+		format.Name = name
+	}
+	request.ResponseFormat = format
+
+	retries := newOptions(providerInfoOf(llm).options, opts...).StructuredOutputRetries
+	for attempt := 0; ; attempt++ {
+		output, err := llm.Completion(ctx, &request, opts...)
+		var schemaErr *SchemaValidationError
+		if err != nil && !errors.As(err, &schemaErr) {
+			return result, output, err
+		}
+		if err == nil && len(output.ToolCalls) > 0 {
+			return result, output, NewLLMError(provider, "TOOL_CALLS", "模型请求调用工具，未返回结构化输出", nil)
+		}
+		if err == nil {
+			result = *new(T)
+			if err = json.Unmarshal([]byte(output.Content), &result); err == nil {
+				return result, output, nil
+			}
+			err = NewLLMError(provider, "DECODE_ERROR", "解码结构化输出失败", err)
+		}
+		if attempt >= retries {
+			return result, output, err
+		}
+
+		// 将错误反馈给模型，要求其修正输出
+		request.Messages = append(request.Messages,
+			AssistantMessage(output.Content),
+			UserMessage(fmt.Sprintf("上一次的回复不符合要求的JSON格式：%v\n请修正后重新输出完整的JSON。", err)),
+		)
+	}
+}
@@ -0,0 +1,295 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	anthropic "github.com/anthropics/anthropic-sdk-go"
+)
+
+var minTemperature, maxTemperature = -100.0, 100.0
+
+var weatherFormat = &ResponseFormat{
+	Name: "weather",
+	Schema: &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"city":        {Type: "string"},
+			"temperature": {Type: "number", Minimum: &minTemperature, Maximum: &maxTemperature},
+			"condition":   {Type: "string", Enum: []any{"sunny", "rainy"}},
+			"tags":        {Type: "array", Items: &JSONSchema{Type: "string"}},
+		},
+		Required: []string{"city", "temperature"},
+	},
+}
+
+func TestResponseFormat_ValidateContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantPath string
+	}{
+		{name: "valid", content: `{"city":"北京","temperature":25,"condition":"sunny","tags":["a"]}`},
+		{name: "optional null", content: `{"city":"北京","temperature":25,"condition":null}`},
+		{name: "invalid json", content: `{"city":`, wantPath: "$"},
+		{name: "missing required", content: `{"city":"北京"}`, wantPath: "$.temperature"},
+		{name: "wrong type", content: `{"city":1,"temperature":25}`, wantPath: "$.city"},
+		{name: "out of range", content: `{"city":"北京","temperature":250}`, wantPath: "$.temperature"},
+		{name: "not in enum", content: `{"city":"北京","temperature":25,"condition":"snowy"}`, wantPath: "$.condition"},
+		{name: "array item", content: `{"city":"北京","temperature":25,"tags":["a",1]}`, wantPath: "$.tags[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := weatherFormat.ValidateContent(tt.content)
+			if tt.wantPath == "" {
+				if err != nil {
+					t.Errorf("ValidateContent() error = %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaValidationError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("Expected *SchemaValidationError, got %v", err)
+			}
+			if schemaErr.Path != tt.wantPath || schemaErr.Content != tt.content {
+				t.Errorf("Unexpected error: path=%s content=%s", schemaErr.Path, schemaErr.Content)
+			}
+		})
+	}
+}
+
+func TestJSONSchema_ToOpenAIStrictSchema(t *testing.T) {
+	schema := weatherFormat.Schema.ToOpenAIStrictSchema()
+	if schema["additionalProperties"] != false {
+		t.Error("Expected additionalProperties false")
+	}
+	required, _ := schema["required"].([]string)
+	if len(required) != 4 {
+		t.Errorf("Expected all properties to be required, got %v", required)
+	}
+	properties := schema["properties"].(map[string]any)
+	if typ, ok := properties["city"].(map[string]any)["type"].(string); !ok || typ != "string" {
+		t.Errorf("Expected required property to keep its type, got %v", properties["city"])
+	}
+	condition := properties["condition"].(map[string]any)
+	if typ, ok := condition["type"].([]string); !ok || typ[1] != "null" {
+		t.Errorf("Expected optional property to be nullable, got %v", condition["type"])
+	}
+	if enum := condition["enum"].([]any); len(enum) != 3 || enum[2] != nil {
+		t.Errorf("Expected null in enum, got %v", enum)
+	}
+}
+
+func TestResponseFormat_StrictFallback(t *testing.T) {
+	type scores struct {
+		Name   string         `json:"name"`
+		Scores map[string]int `json:"scores"`
+	}
+	schema, err := SchemaFor(scores{})
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+	if schema.SupportsOpenAIStrict() || !weatherFormat.Schema.SupportsOpenAIStrict() {
+		t.Error("Expected only schemas without maps to support strict mode")
+	}
+
+	untyped := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{"extra": {Description: "任意值"}}}
+	strict := untyped.ToOpenAIStrictSchema()["properties"].(map[string]any)
+	if _, ok := strict["extra"].(map[string]any)["type"]; ok {
+		t.Errorf("Expected no type for a schema without one, got %v", strict["extra"])
+	}
+
+	input := &Input{Model: "gpt-4o", Messages: []Message{UserMessage("打分")}, ResponseFormat: &ResponseFormat{Name: "scores", Schema: schema}}
+	params, err := CreateOpenAI(APIKey("test")).GenerateOpenAIChatCompletionNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateOpenAIChatCompletionNewParams() error = %v", err)
+	}
+	rf := params.ResponseFormat.OfJSONSchema
+	if rf == nil || rf.JSONSchema.Strict.Or(true) {
+		t.Fatalf("Expected non-strict json_schema for a map field, got %+v", params.ResponseFormat)
+	}
+	properties := rf.JSONSchema.Schema.(map[string]any)["properties"].(map[string]any)
+	if _, ok := properties["scores"].(map[string]any)["additionalProperties"].(map[string]any); !ok {
+		t.Errorf("Expected the map value schema to be kept, got %v", properties["scores"])
+	}
+
+	responsesParams, err := CreateOpenAIResponses(APIKey("test")).GenerateResponseNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateResponseNewParams() error = %v", err)
+	}
+	if rf := responsesParams.Text.Format.OfJSONSchema; rf == nil || rf.Strict.Or(true) {
+		t.Errorf("Expected non-strict json_schema text format, got %+v", responsesParams.Text.Format)
+	}
+}
+
+func TestResponseFormat_Params(t *testing.T) {
+	input := &Input{Model: "gpt-4o", Messages: []Message{UserMessage("北京天气")}, ResponseFormat: weatherFormat}
+
+	openaiParams, err := CreateOpenAI(APIKey("test")).GenerateOpenAIChatCompletionNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateOpenAIChatCompletionNewParams() error = %v", err)
+	}
+	if rf := openaiParams.ResponseFormat.OfJSONSchema; rf == nil || rf.JSONSchema.Name != "weather" || !rf.JSONSchema.Strict.Or(false) {
+		t.Errorf("Expected strict json_schema response format, got %+v", openaiParams.ResponseFormat)
+	}
+
+	responsesParams, err := CreateOpenAIResponses(APIKey("test")).GenerateResponseNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateResponseNewParams() error = %v", err)
+	}
+	if rf := responsesParams.Text.Format.OfJSONSchema; rf == nil || rf.Name != "weather" {
+		t.Errorf("Expected json_schema text format, got %+v", responsesParams.Text.Format)
+	}
+
+	_, config, err := (&Gemini{}).GenerateGeminiContents(input)
+	if err != nil {
+		t.Fatalf("GenerateGeminiContents() error = %v", err)
+	}
+	if config.ResponseMIMEType != "application/json" || config.ResponseSchema == nil {
+		t.Errorf("Expected json response schema, got %q %+v", config.ResponseMIMEType, config.ResponseSchema)
+	}
+
+	anthropicParams, err := CreateAnthropic(APIKey("test")).GenerateAnthropicMessageNewParams(input)
+	if err != nil {
+		t.Fatalf("GenerateAnthropicMessageNewParams() error = %v", err)
+	}
+	if len(anthropicParams.Tools) != 1 || anthropicParams.ToolChoice.OfTool == nil || anthropicParams.ToolChoice.OfTool.Name != "weather" {
+		t.Errorf("Expected forced weather tool, got %+v", anthropicParams.ToolChoice)
+	}
+
+	invalid := &Input{Messages: input.Messages, ResponseFormat: &ResponseFormat{Name: "bad name", Schema: weatherFormat.Schema}}
+	if _, err := CreateOpenAI(APIKey("test")).GenerateOpenAIChatCompletionNewParams(invalid); err == nil {
+		t.Error("Expected error for invalid format name")
+	}
+}
+
+func TestResponseFormat_AnthropicStructuredOutput(t *testing.T) {
+	message := &anthropic.Message{
+		StopReason: anthropic.StopReasonToolUse,
+		Content: []anthropic.ContentBlockUnion{
+			{Type: "tool_use", ID: "toolu_1", Name: "weather", Input: json.RawMessage(`{"city": "北京", "temperature": 25}`)},
+		},
+	}
+	output, err := fromAnthropicResponse(message, time.Now(), weatherFormat)
+	if err != nil {
+		t.Fatalf("fromAnthropicResponse() error = %v", err)
+	}
+	if output.ToolCalls != nil || output.FinishReason != string(FinishReasonStop) {
+		t.Errorf("Expected structured output tool call to be removed, got %+v", output)
+	}
+
+	output, err = validateStructuredOutput(ProviderClaude, &Input{ResponseFormat: weatherFormat}, output)
+	if err != nil {
+		t.Errorf("validateStructuredOutput() error = %v, content %s", err, output.Content)
+	}
+}
+
+type weatherReport struct {
+	City        string   `json:"city"`
+	Temperature float64  `json:"temperature"`
+	Condition   *string  `json:"condition"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func TestCompletionAs(t *testing.T) {
+	llm := &mockLLM{provider: "mock", responses: []mockResponse{
+		{output: &Output{Content: `{"city":"北京"}`}},
+		{output: &Output{Content: `{"city":"北京","temperature":25}`}},
+	}}
+
+	input := &Input{Messages: []Message{UserMessage("北京天气")}}
+	report, output, err := CompletionAs[weatherReport](context.Background(), llm, input, StructuredOutputRetries(1))
+	if err != nil {
+		t.Fatalf("CompletionAs() error = %v", err)
+	}
+	if report.City != "北京" || report.Temperature != 25 || output == nil {
+		t.Errorf("Unexpected result: %+v", report)
+	}
+
+	if len(llm.inputs) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(llm.inputs))
+	}
+	format := llm.inputs[0].ResponseFormat
+	if format == nil || format.Name != "weatherReport" {
+		t.Fatalf("Expected weatherReport response format, got %+v", format)
+	}
+	if !slices.Equal(format.Schema.Required, []string{"city", "temperature"}) {
+		t.Errorf("Expected required city and temperature, got %v", format.Schema.Required)
+	}
+	// 重试时需要带上上一次的回复和错误信息
+	retry := llm.inputs[1].Messages
+	if len(retry) != 3 || retry[1].Role != RoleAssistant || !strings.Contains(retry[2].Content, "$.temperature") {
+		t.Errorf("Expected retry messages with the validation error, got %+v", retry)
+	}
+	if len(input.Messages) != 1 || input.ResponseFormat != nil {
+		t.Error("Expected caller input to be left untouched")
+	}
+}
+
+func TestCompletionAs_ToolCalls(t *testing.T) {
+	llm := &mockLLM{provider: "mock", responses: []mockResponse{
+		{output: weatherToolCallOutput("call_1", "北京")},
+	}}
+
+	input := &Input{Messages: []Message{UserMessage("北京天气")}, Tools: Tools}
+	_, output, err := CompletionAs[weatherReport](context.Background(), llm, input, StructuredOutputRetries(2))
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "TOOL_CALLS" {
+		t.Fatalf("Expected TOOL_CALLS error, got %v", err)
+	}
+	if output == nil || len(output.ToolCalls) != 1 || len(llm.inputs) != 1 {
+		t.Errorf("Expected the tool calls to be returned without retrying, got %+v after %d calls", output, len(llm.inputs))
+	}
+}
+
+func TestCompletionAs_ClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{\"city\":\"北京\"}`
+		if calls.Add(1) > 1 {
+			content = `{\"city\":\"北京\",\"temperature\":25}`
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id": "1", "object": "chat.completion", "created": 1, "model": "m",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "%s"}}]}`, content)
+	}))
+	defer server.Close()
+
+	client := CreateOpenAI(URL(server.URL), APIKey("test"), StructuredOutputRetries(1))
+	report, _, err := CompletionAs[weatherReport](context.Background(), client, &Input{Model: "m", Messages: []Message{UserMessage("北京天气")}})
+	if err != nil || report.Temperature != 25 || calls.Load() != 2 {
+		t.Errorf("Expected the client-level retry to be used, got %+v, %v after %d calls", report, err, calls.Load())
+	}
+}
+
+func TestCompletionAs_NoRetry(t *testing.T) {
+	llm := &mockLLM{provider: "mock", responses: []mockResponse{
+		{output: &Output{Content: `not json`}},
+	}}
+
+	_, output, err := CompletionAs[weatherReport](context.Background(), llm, &Input{Messages: []Message{UserMessage("北京天气")}})
+	var schemaErr *SchemaValidationError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected *SchemaValidationError, got %v", err)
+	}
+	if output == nil || output.Content != "not json" {
+		t.Errorf("Expected output to be returned with the error, got %+v", output)
+	}
+	if len(llm.inputs) != 1 {
+		t.Errorf("Expected a single call, got %d", len(llm.inputs))
+	}
+
+	if _, _, err := CompletionAs[map[int]string](context.Background(), llm, &Input{}); err == nil {
+		t.Error("Expected error for unsupported type")
+	}
+}
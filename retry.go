@@ -0,0 +1,194 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// ============================================================================
+// 重试 / Retry
+// ============================================================================
+
+// RetryAttemptsKey Output.Extra 中记录实际调用次数的键
+// RetryAttemptsKey is the Output.Extra key holding the number of attempts made
+const RetryAttemptsKey = "retry_attempts"
+
+// RetryAttempt 一次失败后即将进行的重试
+// RetryAttempt describes a retry about to be made after a failed attempt
+type RetryAttempt struct {
+	Attempt int           `json:"attempt"` // 失败的调用序号，从 1 开始 / Number of the failed attempt, starting at 1
+	Err     error         `json:"err"`     // 失败原因 / Cause of the failure
+	Delay   time.Duration `json:"delay"`   // 下一次调用前的等待时间 / Wait before the next attempt
+}
+
+// RetryOptions 重试配置选项
+// RetryOptions defines configuration options for retries
+type RetryOptions struct {
+	MaxAttempts    int                // 最大调用次数（包含首次调用）/ Maximum number of attempts, including the first
+	InitialBackoff time.Duration      // 首次重试的退避时间 / Backoff before the first retry
+	MaxBackoff     time.Duration      // 单次退避时间上限 / Upper bound of a single backoff
+	MaxElapsedTime time.Duration      // 从首次调用开始的总时间上限，0 表示不限制 / Total time budget from the first attempt, 0 means unlimited
+	OnRetry        func(RetryAttempt) // 每次重试前的回调 / Callback invoked before each retry
+}
+
+// RetryOption 重试配置函数类型
+// RetryOption is a function type for configuring RetryOptions
+type RetryOption func(*RetryOptions)
+
+// MaxAttempts 设置最大调用次数（包含首次调用）
+// MaxAttempts sets the maximum number of attempts, including the first
+func MaxAttempts(attempts int) RetryOption {
+	return func(options *RetryOptions) {
+		options.MaxAttempts = attempts
+	}
+}
+
+// Backoff 设置指数退避的初始时间和上限
+// Backoff sets the initial and maximum exponential backoff
+func Backoff(initial, maximum time.Duration) RetryOption {
+	return func(options *RetryOptions) {
+		options.InitialBackoff = initial
+		options.MaxBackoff = maximum
+	}
+}
+
+// MaxElapsedTime 设置从首次调用开始的总时间上限，超过后不再重试
+// MaxElapsedTime sets the total time budget from the first attempt, after which no more retries are made
+func MaxElapsedTime(elapsed time.Duration) RetryOption {
+	return func(options *RetryOptions) {
+		options.MaxElapsedTime = elapsed
+	}
+}
+
+// OnRetry 设置每次重试前的回调
+// OnRetry sets the callback invoked before each retry
+func OnRetry(fn func(RetryAttempt)) RetryOption {
+	return func(options *RetryOptions) {
+		options.OnRetry = fn
+	}
+}
+
+var (
+	_ LLM           = (*Retry)(nil)
+	_ EventStreamer = (*Retry)(nil)
+)
+
+// Retry 为 LLM 增加带抖动的指数退避重试，只重试 Retryable 的错误，并优先使用提供商返回的 Retry-After
+// 流式调用只在第一个事件发出之前重试，避免重复输出
+// Retry adds jittered exponential backoff retries to an LLM, retrying only Retryable errors and preferring
+// the provider's Retry-After
+// Streaming calls are only retried before the first event has been emitted, so no output is duplicated
+type Retry struct {
+	llm     LLM
+	options RetryOptions
+}
+
+// NewRetry 创建重试包装，默认最多调用 3 次，退避时间从 500ms 开始、上限 30s，总时间上限 2 分钟
+// NewRetry creates a retrying wrapper; by default it makes up to 3 attempts, backing off from 500ms up to 30s,
+// within 2 minutes in total
+func NewRetry(llm LLM, opts ...RetryOption) *Retry {
+	options := RetryOptions{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		MaxElapsedTime: 2 * time.Minute,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &Retry{llm: llm, options: options}
+}
+
+// Completion 执行单次对话完成（非流式），失败时按配置重试
+// Completion performs a single conversation completion (non-streaming), retrying on failure
+func (r *Retry) Completion(ctx context.Context, input *Input, opts ...Option) (*Output, error) {
+	return r.do(ctx, func() (*Output, bool, error) {
+		output, err := r.llm.Completion(ctx, input, opts...)
+		return output, true, err
+	})
+}
+
+// CompletionStream 执行单次对话完成（流式），只在输出第一段文本之前重试
+// CompletionStream performs a single conversation completion (streaming), retrying only before any text is emitted
+func (r *Retry) CompletionStream(ctx context.Context, input *Input, streamOutput StreamOutput, opts ...Option) (*Output, error) {
+	return r.CompletionStreamEvents(ctx, input, streamOutput.Handler(), opts...)
+}
+
+// CompletionStreamEvents 执行单次对话完成（流式），以类型化事件输出，只在发出第一个事件之前重试
+// CompletionStreamEvents performs a single conversation completion (streaming) with typed events,
+// retrying only before the first event is emitted
+func (r *Retry) CompletionStreamEvents(ctx context.Context, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	return r.do(ctx, func() (*Output, bool, error) {
+		emitted := false
+		output, err := CompletionStreamEvents(ctx, r.llm, input, func(event StreamEvent) {
+			emitted = true
+			handler.send(event)
+		}, opts...)
+		return output, !emitted, err
+	})
+}
+
+// Provider 返回被包装 LLM 的提供商信息
+// Provider returns the provider information of the wrapped LLM
+func (r *Retry) Provider() ProviderInfo {
+	return providerInfoOf(r.llm)
+}
+
+// do 执行 call 直到成功、错误不可重试、call 报告不可重试或超出次数和时间限制
+// do runs call until it succeeds, the error is not retryable, call reports it cannot be retried,
+// or the attempt or time budget is exhausted
+func (r *Retry) do(ctx context.Context, call func() (output *Output, retryable bool, err error)) (*Output, error) {
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		output, retryable, err := call()
+		if err == nil {
+			if output.Extra == nil {
+				output.Extra = make(map[string]any)
+			}
+			output.Extra[RetryAttemptsKey] = attempt
+			return output, nil
+		}
+		if !retryable || !IsRetryable(err) || attempt >= r.options.MaxAttempts {
+			return output, err
+		}
+
+		delay := r.backoff(attempt, err)
+		if r.options.MaxElapsedTime > 0 && time.Since(start)+delay > r.options.MaxElapsedTime {
+			return output, err
+		}
+		if r.options.OnRetry != nil {
+			r.options.OnRetry(RetryAttempt{Attempt: attempt, Err: err, Delay: delay})
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 计算第 attempt 次失败后的等待时间：优先使用错误中的 Retry-After，
+// 否则为指数退避时间在 [1/2, 1] 区间内的随机值
+// backoff computes the wait after the attempt-th failure: the error's Retry-After when present,
+// otherwise a random value between half and all of the exponential backoff
+func (r *Retry) backoff(attempt int, err error) time.Duration {
+	var llmErr *LLMError
+	if errors.As(err, &llmErr) && llmErr.RetryAfter > 0 {
+		return llmErr.RetryAfter
+	}
+
+	delay := r.options.InitialBackoff
+	for i := 1; i < attempt && delay < r.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, r.options.MaxBackoff)
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
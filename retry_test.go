@@ -0,0 +1,100 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetry_Completion(t *testing.T) {
+	rateLimited := NewLLMError(ProviderOpenAI, ErrCodeRateLimited, "限流", nil)
+	rateLimited.RetryAfter = 20 * time.Millisecond
+	llm := &mockLLM{responses: []mockResponse{
+		{err: NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)},
+		{err: rateLimited},
+		{output: &Output{Content: "你好"}},
+	}}
+
+	var attempts []RetryAttempt
+	output, err := NewRetry(llm, Backoff(time.Millisecond, 4*time.Millisecond), OnRetry(func(attempt RetryAttempt) {
+		attempts = append(attempts, attempt)
+	})).Completion(context.Background(), &Input{})
+	if err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	if output.Content != "你好" || output.Extra[RetryAttemptsKey] != 3 {
+		t.Errorf("Unexpected output: %+v", output)
+	}
+	if len(attempts) != 2 || attempts[0].Attempt != 1 || !errors.Is(attempts[0].Err, ErrOverloaded) {
+		t.Fatalf("Unexpected retry attempts: %+v", attempts)
+	}
+	if attempts[0].Delay < 500*time.Microsecond || attempts[0].Delay > time.Millisecond {
+		t.Errorf("Expected jittered backoff within [0.5ms, 1ms], got %v", attempts[0].Delay)
+	}
+	if attempts[1].Delay != 20*time.Millisecond {
+		t.Errorf("Expected Retry-After to be honoured, got %v", attempts[1].Delay)
+	}
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	llm := &mockLLM{responses: []mockResponse{
+		{err: NewLLMError(ProviderOpenAI, ErrCodeInvalidRequest, "参数错误", nil)},
+		{output: &Output{Content: "你好"}},
+	}}
+	_, err := NewRetry(llm, Backoff(time.Millisecond, time.Millisecond)).Completion(context.Background(), &Input{})
+	if !errors.Is(err, ErrInvalidRequest) || len(llm.inputs) != 1 {
+		t.Errorf("Expected a single attempt with the original error, got %v after %d attempts", err, len(llm.inputs))
+	}
+}
+
+func TestRetry_Limits(t *testing.T) {
+	overloaded := NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)
+
+	// 超出最大次数
+	llm := &mockLLM{responses: []mockResponse{{err: overloaded}}}
+	_, err := NewRetry(llm, MaxAttempts(4), Backoff(time.Millisecond, time.Millisecond)).Completion(context.Background(), &Input{})
+	if !errors.Is(err, ErrOverloaded) || len(llm.inputs) != 4 {
+		t.Errorf("Expected 4 attempts, got %d (%v)", len(llm.inputs), err)
+	}
+
+	// 下一次等待会超出总时间上限
+	llm = &mockLLM{responses: []mockResponse{{err: overloaded}}}
+	_, err = NewRetry(llm, Backoff(time.Second, time.Second), MaxElapsedTime(100*time.Millisecond)).Completion(context.Background(), &Input{})
+	if !errors.Is(err, ErrOverloaded) || len(llm.inputs) != 1 {
+		t.Errorf("Expected to give up after 1 attempt, got %d (%v)", len(llm.inputs), err)
+	}
+
+	// 等待期间取消
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	llm = &mockLLM{responses: []mockResponse{{err: overloaded}}}
+	_, err = NewRetry(llm, Backoff(time.Second, time.Second)).Completion(ctx, &Input{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRetry_CompletionStream(t *testing.T) {
+	overloaded := NewLLMError(ProviderOpenAI, ErrCodeOverloaded, "过载", nil)
+
+	// 首个事件之前失败：重试
+	llm := &mockLLM{responses: []mockResponse{{err: overloaded}, {output: &Output{Content: "你好"}}}}
+	var chunks []string
+	output, err := NewRetry(llm, Backoff(time.Millisecond, time.Millisecond)).CompletionStream(context.Background(), &Input{}, func(content string) {
+		chunks = append(chunks, content)
+	})
+	if err != nil || output.Content != "你好" || len(chunks) != 1 {
+		t.Errorf("Expected a successful retry, got %v, %v, %q", output, err, chunks)
+	}
+
+	// 已经输出内容后失败：不重试，避免重复输出
+	llm = &mockLLM{responses: []mockResponse{{output: &Output{Content: "你"}, err: overloaded}, {output: &Output{Content: "你好"}}}}
+	chunks = nil
+	output, err = NewRetry(llm, Backoff(time.Millisecond, time.Millisecond)).CompletionStream(context.Background(), &Input{}, func(content string) {
+		chunks = append(chunks, content)
+	})
+	if !errors.Is(err, ErrOverloaded) || output.Content != "你" || len(llm.inputs) != 1 || len(chunks) != 1 {
+		t.Errorf("Expected no retry after the first token, got %v after %d attempts, chunks %q", err, len(llm.inputs), chunks)
+	}
+}
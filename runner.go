@@ -0,0 +1,167 @@
+package OpenLLM
+
+import (
+	"context"
+	"slices"
+)
+
+// ============================================================================
+// Agent 循环 / Agent Loop Runner
+// ============================================================================
+
+// defaultRunnerMaxSteps 默认的最大步数
+// defaultRunnerMaxSteps is the default maximum number of steps
+const defaultRunnerMaxSteps = 10
+
+// RunStep 一次模型调用及其工具执行结果
+// RunStep is a single model call together with the results of its tool calls
+type RunStep struct {
+	Index       int       `json:"index"`                  // 步骤序号，从 1 开始 / Step index, starting at 1
+	Output      *Output   `json:"output"`                 // 模型输出 / Model output
+	ToolResults []Message `json:"tool_results,omitempty"` // 工具执行结果消息 / Tool result messages
+}
+
+// RunResult Runner 的执行结果
+// RunResult is the result of a Runner run
+type RunResult struct {
+	Output     *Output    `json:"output"`      // 最后一次模型输出 / The last model output
+	Messages   []Message  `json:"messages"`    // 完整的对话消息（包含新增的助手和工具消息）/ Full conversation including new assistant and tool messages
+	Steps      []RunStep  `json:"steps"`       // 每一步的详情 / Details of each step
+	TokenUsage TokenUsage `json:"token_usage"` // 所有步骤的 token 使用量之和 / Token usage summed over all steps
+}
+
+// RunnerOptions Runner 配置选项
+// RunnerOptions defines configuration options for the Runner
+type RunnerOptions struct {
+	MaxSteps  int           // 最大模型调用次数 / Maximum number of model calls
+	MaxTokens int64         // 累计 token 上限，0 表示不限制 / Cumulative token budget, 0 means unlimited
+	OnStep    func(RunStep) // 每一步完成后的回调 / Callback invoked after each step
+}
+
+// RunnerOption Runner 配置函数类型
+// RunnerOption is a function type for configuring RunnerOptions
+type RunnerOption func(*RunnerOptions)
+
+// MaxSteps 设置最大模型调用次数，小于等于 0 时使用默认值 10
+// MaxSteps sets the maximum number of model calls; values <= 0 use the default of 10
+func MaxSteps(steps int) RunnerOption {
+	return func(options *RunnerOptions) {
+		options.MaxSteps = steps
+	}
+}
+
+// TokenBudget 设置累计 token 上限，超过后停止循环
+// TokenBudget sets the cumulative token budget; the loop stops once it is exceeded
+func TokenBudget(tokens int64) RunnerOption {
+	return func(options *RunnerOptions) {
+		options.MaxTokens = tokens
+	}
+}
+
+// OnStep 设置每一步完成后的回调
+// OnStep sets the callback invoked after each step
+func OnStep(fn func(RunStep)) RunnerOption {
+	return func(options *RunnerOptions) {
+		options.OnStep = fn
+	}
+}
+
+// Runner 驱动模型与工具交替执行，直到模型不再调用工具
+// Runner alternates between the model and tool execution until the model stops calling tools
+type Runner struct {
+	llm      LLM
+	registry *ToolRegistry
+	options  RunnerOptions
+}
+
+// NewRunner 创建 Runner，工具调用通过 registry 执行；registry 为 nil 时视为空注册表
+// NewRunner creates a Runner that executes tool calls through the registry; a nil registry is treated as empty
+func NewRunner(llm LLM, registry *ToolRegistry, opts ...RunnerOption) *Runner {
+	options := RunnerOptions{MaxSteps: defaultRunnerMaxSteps}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.MaxSteps <= 0 {
+		options.MaxSteps = defaultRunnerMaxSteps
+	}
+	if registry == nil {
+		registry = NewToolRegistry()
+	}
+	return &Runner{llm: llm, registry: registry, options: options}
+}
+
+// Run 发送请求并循环执行工具调用，直到模型给出最终回复或超出步数、token 限制
+// Input.Tools 为空时使用注册表中的全部工具；出错时同时返回已完成步骤的结果
+// Run sends the input and executes tool calls in a loop until the model gives a final answer or
+// the step or token budget is exceeded
+// Input.Tools defaults to every tool in the registry; on error the result of the completed steps is returned as well
+// 注册表通过 EnableTracing 启用追踪时，Run 创建 invoke_agent 父 span，模型调用和工具执行的 span 都是它的子 span
+// When the registry has tracing enabled through EnableTracing, Run starts an invoke_agent parent span, and the spans
+// of model calls and tool executions become its children
+func (r *Runner) Run(ctx context.Context, input *Input, opts ...Option) (*RunResult, error) {
+	r.registry.mu.RLock()
+	t := r.registry.tracer
+	r.registry.mu.RUnlock()
+
+	ctx, span := t.startRun(ctx, providerInfoOf(r.llm), input, opts)
+	result, err := r.run(ctx, input, opts...)
+	t.endRun(span, result, err)
+	return result, err
+}
+
+// run 执行 Agent 循环
+// run executes the agent loop
+func (r *Runner) run(ctx context.Context, input *Input, opts ...Option) (*RunResult, error) {
+	provider := providerOf(r.llm)
+
+	// 复制请求，避免修改调用方的 Input
+	request := *input
+	request.Messages = slices.Clone(input.Messages)
+	if len(request.Tools) == 0 {
+		request.Tools = r.registry.Tools()
+	}
+
+	result := &RunResult{}
+	for index := 1; index <= r.options.MaxSteps; index++ {
+		output, err := r.llm.Completion(ctx, &request, opts...)
+		if err != nil {
+			result.Messages = request.Messages
+			return result, err
+		}
+		result.Output = output
+		result.TokenUsage = result.TokenUsage.Add(output.TokenUsage)
+
+		step := RunStep{Index: index, Output: output}
+		if len(output.ToolCalls) == 0 {
+			// 模型给出最终回复 / The model gave its final answer
+			request.Messages = append(request.Messages, AssistantMessage(output.Content))
+			result.Steps = append(result.Steps, step)
+			result.Messages = request.Messages
+			r.onStep(step)
+			return result, nil
+		}
+
+		request.Messages = append(request.Messages, AssistantMessageWithTools(output.Content, output.ToolCalls))
+		step.ToolResults = r.registry.ExecuteAll(ctx, output.ToolCalls)
+		request.Messages = append(request.Messages, step.ToolResults...)
+		result.Steps = append(result.Steps, step)
+		result.Messages = request.Messages
+		r.onStep(step)
+
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if r.options.MaxTokens > 0 && result.TokenUsage.TotalTokens >= r.options.MaxTokens {
+			return result, NewLLMError(provider, "TOKEN_BUDGET_EXCEEDED", "超过token预算", nil)
+		}
+	}
+	return result, NewLLMError(provider, "MAX_STEPS_EXCEEDED", "超过最大步数", nil)
+}
+
+// onStep 调用步骤回调
+// onStep invokes the step callback
+func (r *Runner) onStep(step RunStep) {
+	if r.options.OnStep != nil {
+		r.options.OnStep(step)
+	}
+}
@@ -0,0 +1,120 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func weatherToolCallOutput(id, city string) *Output {
+	return &Output{
+		FinishReason: string(FinishReasonToolCalls),
+		ToolCalls:    []ToolCall{{ID: id, Name: "query_weather", Arguments: map[string]any{"city": city}}},
+		TokenUsage:   TokenUsage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	llm := &mockLLM{provider: "mock", responses: []mockResponse{
+		{output: weatherToolCallOutput("call_1", "北京")},
+		{output: weatherToolCallOutput("call_2", "上海")},
+		{output: &Output{
+			Content:      "北京和上海都是晴天",
+			FinishReason: string(FinishReasonStop),
+			TokenUsage:   TokenUsage{InputTokens: 30, OutputTokens: 10, TotalTokens: 40},
+		}},
+	}}
+
+	var steps []int
+	runner := NewRunner(llm, newWeatherRegistry(t), OnStep(func(step RunStep) { steps = append(steps, step.Index) }))
+	input := &Input{Messages: []Message{UserMessage("北京和上海的天气")}}
+	result, err := runner.Run(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if result.Output.Content != "北京和上海都是晴天" {
+		t.Errorf("Unexpected final output: %q", result.Output.Content)
+	}
+	if len(result.Steps) != 3 || len(steps) != 3 {
+		t.Errorf("Expected 3 steps and callbacks, got %d and %v", len(result.Steps), steps)
+	}
+	if result.TokenUsage.TotalTokens != 70 || result.TokenUsage.InputTokens != 50 {
+		t.Errorf("Unexpected aggregated usage: %+v", result.TokenUsage)
+	}
+
+	// user, assistant(call_1), tool, assistant(call_2), tool, assistant
+	wantRoles := []MessageRole{RoleUser, RoleAssistant, RoleTool, RoleAssistant, RoleTool, RoleAssistant}
+	if len(result.Messages) != len(wantRoles) {
+		t.Fatalf("Expected %d messages, got %d", len(wantRoles), len(result.Messages))
+	}
+	for i, role := range wantRoles {
+		if result.Messages[i].Role != role {
+			t.Errorf("Message %d: expected role %s, got %s", i, role, result.Messages[i].Role)
+		}
+	}
+	if result.Messages[4].ToolCallID != "call_2" {
+		t.Errorf("Expected tool result for call_2, got %+v", result.Messages[4])
+	}
+
+	// 工具默认来自注册表，且不修改调用方的 Input
+	if len(llm.inputs[0].Tools) != 2 {
+		t.Errorf("Expected registry tools to be sent, got %d", len(llm.inputs[0].Tools))
+	}
+	if len(input.Messages) != 1 || input.Tools != nil {
+		t.Error("Expected caller input to be left untouched")
+	}
+}
+
+func TestRunner_Limits(t *testing.T) {
+	looping := func() *mockLLM {
+		return &mockLLM{provider: "mock", responses: []mockResponse{{output: weatherToolCallOutput("call_1", "北京")}}}
+	}
+
+	result, err := NewRunner(looping(), newWeatherRegistry(t), MaxSteps(2)).Run(context.Background(), &Input{})
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "MAX_STEPS_EXCEEDED" {
+		t.Errorf("Expected MAX_STEPS_EXCEEDED, got %v", err)
+	}
+	if len(result.Steps) != 2 {
+		t.Errorf("Expected 2 completed steps, got %d", len(result.Steps))
+	}
+
+	result, err = NewRunner(looping(), newWeatherRegistry(t), TokenBudget(20)).Run(context.Background(), &Input{})
+	if !errors.As(err, &llmErr) || llmErr.Code != "TOKEN_BUDGET_EXCEEDED" {
+		t.Errorf("Expected TOKEN_BUDGET_EXCEEDED, got %v", err)
+	}
+	if len(result.Steps) != 2 || result.TokenUsage.TotalTokens != 30 {
+		t.Errorf("Expected to stop after 2 steps, got %d steps and %+v", len(result.Steps), result.TokenUsage)
+	}
+
+	// 小于等于 0 的步数使用默认值
+	result, err = NewRunner(looping(), newWeatherRegistry(t), MaxSteps(0)).Run(context.Background(), &Input{})
+	if !errors.As(err, &llmErr) || llmErr.Code != "MAX_STEPS_EXCEEDED" || len(result.Steps) != defaultRunnerMaxSteps {
+		t.Errorf("Expected %d steps with MaxSteps(0), got %d steps and %v", defaultRunnerMaxSteps, len(result.Steps), err)
+	}
+
+	apiErr := errors.New("api error")
+	result, err = NewRunner(&mockLLM{responses: []mockResponse{{err: apiErr}}}, newWeatherRegistry(t)).Run(context.Background(), &Input{})
+	if !errors.Is(err, apiErr) || result == nil {
+		t.Errorf("Expected api error with partial result, got %v", err)
+	}
+}
+
+func TestRunner_NilRegistry(t *testing.T) {
+	llm := &mockLLM{provider: "mock", responses: []mockResponse{{output: &Output{Content: "你好"}}}}
+	result, err := NewRunner(llm, nil).Run(context.Background(), &Input{Messages: []Message{UserMessage("hi")}})
+	if err != nil || result.Output.Content != "你好" {
+		t.Fatalf("Expected a nil registry to be treated as empty, got %+v, %v", result, err)
+	}
+
+	// 没有注册的工具时，工具调用返回错误消息而不是 panic
+	llm = &mockLLM{provider: "mock", responses: []mockResponse{
+		{output: weatherToolCallOutput("call_1", "北京")},
+		{output: &Output{Content: "完成"}},
+	}}
+	result, err = NewRunner(llm, nil).Run(context.Background(), &Input{})
+	if err != nil || len(result.Steps) != 2 || !result.Steps[0].ToolResults[0].IsError {
+		t.Errorf("Expected the unknown tool to produce an error message, got %+v, %v", result, err)
+	}
+}
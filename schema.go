@@ -0,0 +1,373 @@
+package OpenLLM
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 基于反射的 JSONSchema 生成 / Reflection-based JSONSchema Generation
+// ============================================================================
+
+var (
+	// timeType time.Time 类型，按 RFC 3339 字符串编码
+	// timeType is the time.Time type, encoded as an RFC 3339 string
+	timeType = reflect.TypeFor[time.Time]()
+
+	// contextType context.Context 接口类型
+	// contextType is the context.Context interface type
+	contextType = reflect.TypeFor[context.Context]()
+)
+
+// SchemaFor 根据 Go 值的类型生成 JSONSchema
+// 支持的结构体标签：
+//   - json: 字段名、omitempty 和 "-"，与 encoding/json 一致
+//   - jsonschema: 逗号分隔的 description=...、enum=...（可重复）、minimum=...、maximum=...，值中的逗号在标签中写作 \\,
+//
+// 指针或带 omitempty 的字段为可选字段，time.Time 生成 date-time 格式的字符串
+// SchemaFor derives a JSONSchema from the type of a Go value
+// Supported struct tags:
+//   - json: field name, omitempty and "-", same as encoding/json
+//   - jsonschema: comma separated description=..., enum=... (repeatable), minimum=..., maximum=...; write commas in values as \\, in the tag
+//
+// Pointer or omitempty fields are optional, and time.Time produces a date-time formatted string
+func SchemaFor(v any) (*JSONSchema, error) {
+	if v == nil {
+		return nil, fmt.Errorf("无法为nil生成JSONSchema")
+	}
+	return schemaForType(reflect.TypeOf(v))
+}
+
+// schemaForType 根据 Go 类型生成 JSONSchema
+// 结构体字段按 json 标签命名，指针或带 omitempty 的字段为可选字段，匿名嵌入的结构体字段会被展开
+// schemaForType derives a JSONSchema from a Go type
+// Struct fields are named by their json tags, pointer or omitempty fields are optional,
+// and fields of embedded structs are promoted
+func schemaForType(t reflect.Type) (*JSONSchema, error) {
+	return schemaForTypeVisiting(t, map[reflect.Type]bool{})
+}
+
+// schemaForTypeVisiting 递归生成 JSONSchema，visiting 用于检测递归类型
+// schemaForTypeVisiting recursively derives the JSONSchema, using visiting to detect recursive types
+func schemaForTypeVisiting(t reflect.Type, visiting map[reflect.Type]bool) (*JSONSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}, nil
+
+	case reflect.Slice, reflect.Array:
+		// []byte 按 encoding/json 的规则编码为 base64 字符串
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string"}, nil
+		}
+		items, err := schemaForTypeVisiting(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("map的键必须是字符串类型，当前为: %s", t.Key())
+		}
+		values, err := schemaForTypeVisiting(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return &JSONSchema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Struct:
+		if visiting[t] {
+			return nil, fmt.Errorf("不支持递归类型: %s", t)
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+		if err := addStructFields(schema, t, visiting); err != nil {
+			return nil, err
+		}
+		return schema, nil
+
+	default:
+		return nil, fmt.Errorf("不支持的类型: %s", t)
+	}
+}
+
+// structField 结构体（包括展开的嵌入结构体）中的 JSON 字段
+// structField is a JSON field of a struct, including the fields promoted from embedded structs
+type structField struct {
+	name      string
+	field     reflect.StructField
+	omitempty bool
+	depth     int  // 嵌入深度，0 表示外层结构体 / Embedding depth, 0 for the outer struct
+	tagged    bool // 名称是否来自 json 标签 / Whether the name comes from the json tag
+}
+
+// addStructFields 将结构体字段添加到 object 类型的 Schema 中
+// 同名字段按 encoding/json 的规则取舍：嵌入层级最浅的字段优先，同一层级只有一个带 json 标签的字段时取该字段，否则忽略所有同名字段
+// addStructFields adds the struct fields to the object schema
+// Fields with the same name are resolved like encoding/json does: the shallowest field wins, and among fields at
+// the same depth a single json-tagged field wins; otherwise all of them are dropped
+func addStructFields(schema *JSONSchema, t reflect.Type, visiting map[reflect.Type]bool) error {
+	var fields []structField
+	collectStructFields(t, 0, visiting, &fields)
+
+	byName := make(map[string][]structField)
+	var names []string
+	for _, f := range fields {
+		if _, ok := byName[f.name]; !ok {
+			names = append(names, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	for _, name := range names {
+		f, ok := dominantField(byName[name])
+		if !ok {
+			continue
+		}
+		prop, err := schemaForTypeVisiting(f.field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("字段 %s: %w", f.field.Name, err)
+		}
+		if err := applySchemaTag(prop, f.field.Tag.Get("jsonschema")); err != nil {
+			return fmt.Errorf("字段 %s: %w", f.field.Name, err)
+		}
+		schema.Properties[name] = prop
+		if !f.omitempty && f.field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return nil
+}
+
+// collectStructFields 按声明顺序收集结构体的 JSON 字段，展开匿名嵌入的结构体
+// 已在 visiting 中的嵌入类型（如 type N struct{ *N }）与 encoding/json 一样被忽略
+// collectStructFields collects the JSON fields of a struct in declaration order, promoting embedded structs
+// Embedded types already in visiting (such as type N struct{ *N }) are ignored, as encoding/json does
+func collectStructFields(t reflect.Type, depth int, visiting map[reflect.Type]bool, fields *[]structField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		// 未指定 json 名称的匿名结构体，其字段会被 encoding/json 展开到外层
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && fieldType != timeType {
+			if visiting[fieldType] {
+				continue
+			}
+			visiting[fieldType] = true
+			collectStructFields(fieldType, depth+1, visiting, fields)
+			delete(visiting, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = field.Name
+		}
+		*fields = append(*fields, structField{name: name, field: field, omitempty: omitempty, depth: depth, tagged: tagged})
+	}
+}
+
+// dominantField 从同名字段中选出 encoding/json 会使用的字段，没有唯一结果时返回 false
+// dominantField picks the field encoding/json uses among fields with the same name, returning false when it is ambiguous
+func dominantField(fields []structField) (structField, bool) {
+	depth := fields[0].depth
+	for _, f := range fields[1:] {
+		depth = min(depth, f.depth)
+	}
+
+	var shallowest, tagged []structField
+	for _, f := range fields {
+		if f.depth != depth {
+			continue
+		}
+		shallowest = append(shallowest, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return structField{}, false
+	}
+}
+
+// jsonFieldName 解析字段的 json 标签，返回字段名、是否 omitempty 以及是否忽略该字段
+// jsonFieldName parses the json tag, returning the name, whether it is omitempty and whether to skip the field
+func jsonFieldName(field reflect.StructField) (name string, omitempty, skip bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false, !field.Anonymous && !field.IsExported()
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" || opt == "omitzero" {
+			omitempty = true
+		}
+	}
+	return name, omitempty, false
+}
+
+// applySchemaTag 将 jsonschema 标签应用到 Schema，数组字段的 enum/minimum/maximum 作用于数组元素
+// applySchemaTag applies the jsonschema tag to the schema; enum/minimum/maximum of array fields apply to the items
+func applySchemaTag(schema *JSONSchema, tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	target := schema
+	if schema.Type == "array" && schema.Items != nil {
+		target = schema.Items
+	}
+
+	for _, item := range splitSchemaTag(tag) {
+		key, value, _ := strings.Cut(item, "=")
+		switch key {
+		case "description":
+			schema.Description = value
+		case "enum":
+			enum, err := parseSchemaValue(target.Type, value)
+			if err != nil {
+				return fmt.Errorf("无效的enum值 %q: %w", value, err)
+			}
+			target.Enum = append(target.Enum, enum)
+		case "minimum", "maximum":
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("无效的%s值 %q: %w", key, value, err)
+			}
+			if key == "minimum" {
+				target.Minimum = &number
+			} else {
+				target.Maximum = &number
+			}
+		default:
+			return fmt.Errorf("不支持的jsonschema标签: %s", key)
+		}
+	}
+	return nil
+}
+
+// splitSchemaTag 按逗号拆分 jsonschema 标签，支持 \, 转义
+// splitSchemaTag splits the jsonschema tag on commas, honouring \, escapes
+func splitSchemaTag(tag string) []string {
+	var items []string
+	var current strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			items = append(items, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(tag[i])
+		}
+	}
+	return append(items, current.String())
+}
+
+// parseSchemaValue 按 Schema 类型解析标签中的值
+// parseSchemaValue parses a tag value according to the schema type
+func parseSchemaValue(schemaType, value string) (any, error) {
+	switch schemaType {
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+// ============================================================================
+// 基于反射的工具定义 / Reflection-based Tool Definitions
+// ============================================================================
+
+// NewTool 根据参数类型 Args 创建工具定义，Args 必须是结构体
+// NewTool creates a tool definition from the argument type Args, which must be a struct
+func NewTool[Args any](name, description string) (*Tool, error) {
+	return newToolFromType(name, description, reflect.TypeFor[Args]())
+}
+
+// NewToolFromFunc 根据函数的参数类型创建工具定义
+// fn 的签名必须是 func(Args) 或 func(context.Context, Args)，返回值不限，Args 必须是结构体
+// NewToolFromFunc creates a tool definition from the argument type of a function
+// fn must have the signature func(Args) or func(context.Context, Args) with any results, where Args is a struct
+func NewToolFromFunc(name, description string, fn any) (*Tool, error) {
+	argsType, err := funcArgsType(reflect.TypeOf(fn))
+	if err != nil {
+		return nil, fmt.Errorf("工具 %s: %w", name, err)
+	}
+	return newToolFromType(name, description, argsType)
+}
+
+// funcArgsType 返回函数的参数类型（跳过第一个 context.Context 参数）
+// funcArgsType returns the argument type of the function, skipping a leading context.Context
+func funcArgsType(fnType reflect.Type) (reflect.Type, error) {
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("fn必须是函数，当前为: %v", fnType)
+	}
+	switch {
+	case fnType.NumIn() == 1:
+		return fnType.In(0), nil
+	case fnType.NumIn() == 2 && fnType.In(0) == contextType:
+		return fnType.In(1), nil
+	default:
+		return nil, fmt.Errorf("函数签名必须是 func(Args) 或 func(context.Context, Args)，当前为: %s", fnType)
+	}
+}
+
+// newToolFromType 根据参数类型创建并验证工具定义
+// newToolFromType creates and validates a tool definition from the argument type
+func newToolFromType(name, description string, argsType reflect.Type) (*Tool, error) {
+	parameters, err := schemaForType(argsType)
+	if err != nil {
+		return nil, fmt.Errorf("工具 %s 生成参数Schema失败: %w", name, err)
+	}
+	tool := &Tool{Name: name, Description: description, Parameters: parameters}
+	if err := tool.Validate(); err != nil {
+		return nil, fmt.Errorf("工具 %s: %w", name, err)
+	}
+	return tool, nil
+}
@@ -0,0 +1,166 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+type schemaBase struct {
+	ID string `json:"id" jsonschema:"description=唯一标识"`
+}
+
+type schemaAddress struct {
+	City string `json:"city"`
+}
+
+type schemaArgs struct {
+	schemaBase
+	Query    string            `json:"query" jsonschema:"description=搜索关键词\\, 支持中文"`
+	Limit    int               `json:"limit,omitempty" jsonschema:"minimum=1,maximum=100"`
+	Lang     string            `json:"lang" jsonschema:"enum=zh,enum=en"`
+	Levels   []int             `json:"levels" jsonschema:"enum=1,enum=2"`
+	Address  *schemaAddress    `json:"address"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Since    time.Time         `json:"since"`
+	Internal string            `json:"-"`
+	hidden   string
+}
+
+func TestSchemaFor(t *testing.T) {
+	schema, err := SchemaFor(schemaArgs{})
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+	if err := schema.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	wantRequired := []string{"id", "query", "lang", "levels", "since"}
+	if !slices.Equal(schema.Required, wantRequired) {
+		t.Errorf("Expected required %v, got %v", wantRequired, schema.Required)
+	}
+	if len(schema.Properties) != 8 {
+		t.Errorf("Expected 8 properties, got %d", len(schema.Properties))
+	}
+
+	props := schema.Properties
+	if props["id"].Description != "唯一标识" {
+		t.Errorf("Expected embedded field description, got %q", props["id"].Description)
+	}
+	if props["query"].Description != "搜索关键词, 支持中文" {
+		t.Errorf("Expected escaped comma in description, got %q", props["query"].Description)
+	}
+	if props["limit"].Type != "integer" || *props["limit"].Minimum != 1 || *props["limit"].Maximum != 100 {
+		t.Errorf("Unexpected limit schema: %+v", props["limit"])
+	}
+	if !slices.Equal(props["lang"].Enum, []any{"zh", "en"}) {
+		t.Errorf("Unexpected lang enum: %v", props["lang"].Enum)
+	}
+	if props["levels"].Type != "array" || !slices.Equal(props["levels"].Items.Enum, []any{int64(1), int64(2)}) {
+		t.Errorf("Expected enum on array items, got %+v", props["levels"].Items)
+	}
+	if props["address"].Type != "object" || props["address"].Properties["city"].Type != "string" {
+		t.Errorf("Unexpected address schema: %+v", props["address"])
+	}
+	if props["labels"].AdditionalProperties == nil || props["labels"].AdditionalProperties.Type != "string" {
+		t.Errorf("Unexpected labels schema: %+v", props["labels"])
+	}
+	if props["since"].Type != "string" || props["since"].Format != "date-time" {
+		t.Errorf("Unexpected since schema: %+v", props["since"])
+	}
+}
+
+type schemaNode struct {
+	*schemaNode
+	Name string `json:"name"`
+}
+
+type schemaInner struct {
+	Name string `jsonschema:"description=内层"`
+	Note string
+}
+
+type schemaOther struct {
+	Note string
+}
+
+type schemaConflict struct {
+	schemaInner
+	schemaOther
+	Name string `jsonschema:"description=外层"`
+}
+
+func TestSchemaFor_EmbeddedFields(t *testing.T) {
+	schema, err := SchemaFor(schemaConflict{})
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+	// 外层的 Name 覆盖嵌入的 Name；同层两个 Note 有歧义，encoding/json 会忽略
+	if !slices.Equal(schema.Required, []string{"Name"}) || len(schema.Properties) != 1 || schema.Properties["Name"].Description != "外层" {
+		t.Errorf("Expected only the outer Name, got required %v and properties %v", schema.Required, schema.Properties)
+	}
+	encoded, _ := json.Marshal(schemaConflict{Name: "outer", schemaInner: schemaInner{Name: "inner", Note: "a"}, schemaOther: schemaOther{Note: "b"}})
+	if string(encoded) != `{"Name":"outer"}` {
+		t.Errorf("Expected the schema to match encoding/json, got %s", encoded)
+	}
+
+	node, err := SchemaFor(schemaNode{})
+	if err != nil {
+		t.Fatalf("SchemaFor() error = %v", err)
+	}
+	if len(node.Properties) != 1 || node.Properties["name"] == nil {
+		t.Errorf("Expected the self-embedded pointer to be ignored, got %v", node.Properties)
+	}
+}
+
+func TestSchemaFor_Errors(t *testing.T) {
+	type recursive struct {
+		Children []recursive `json:"children"`
+	}
+	type badTag struct {
+		Limit int `json:"limit" jsonschema:"minimum=one"`
+	}
+
+	for name, v := range map[string]any{
+		"nil":       nil,
+		"recursive": recursive{},
+		"channel":   struct{ C chan int }{},
+		"map key":   map[int]string{},
+		"bad tag":   badTag{},
+	} {
+		if _, err := SchemaFor(v); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNewTool(t *testing.T) {
+	tool, err := NewTool[schemaArgs]("search", "搜索")
+	if err != nil {
+		t.Fatalf("NewTool() error = %v", err)
+	}
+	if tool.Name != "search" || tool.Parameters.Properties["query"] == nil {
+		t.Errorf("Unexpected tool: %+v", tool)
+	}
+	if _, err := tool.ToOpenAITool(); err != nil {
+		t.Errorf("ToOpenAITool() error = %v", err)
+	}
+
+	fnTool, err := NewToolFromFunc("search", "搜索", func(ctx context.Context, args schemaArgs) (string, error) { return "", nil })
+	if err != nil {
+		t.Fatalf("NewToolFromFunc() error = %v", err)
+	}
+	if len(fnTool.Parameters.Properties) != len(tool.Parameters.Properties) {
+		t.Errorf("Expected same parameters as NewTool, got %+v", fnTool.Parameters)
+	}
+
+	if _, err := NewTool[string]("bad", "参数不是结构体"); err == nil {
+		t.Error("Expected error for non-struct args")
+	}
+	if _, err := NewToolFromFunc("bad", "多余参数", func(a, b, c int) {}); err == nil {
+		t.Error("Expected error for invalid function signature")
+	}
+}
@@ -0,0 +1,343 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 流式事件 / Streaming Events
+// ============================================================================
+
+// StreamEventType 流式事件类型
+// StreamEventType represents the kind of a streaming event
+type StreamEventType string
+
+const (
+	StreamEventContent  StreamEventType = "content"         // 回复文本增量 / Answer text delta
+	StreamEventThinking StreamEventType = "thinking"        // 思考文本增量 / Thinking text delta
+	StreamEventToolCall StreamEventType = "tool_call_delta" // 工具调用增量 / Tool call delta
+	StreamEventUsage    StreamEventType = "usage"           // Token使用情况 / Token usage
+	StreamEventFinish   StreamEventType = "finish"          // 结束原因 / Finish reason
+	StreamEventDone     StreamEventType = "done"            // 流结束，携带完整输出（仅 Stream）/ Stream done with the full output (Stream only)
+)
+
+// StreamEvent 流式事件，根据 Type 读取对应的字段
+// StreamEvent is a streaming event; read the field matching its Type
+type StreamEvent struct {
+	Type         StreamEventType `json:"type"`                    // 事件类型 / Event type
+	Content      string          `json:"content,omitempty"`       // 文本增量（content/thinking）/ Text delta (content/thinking)
+	ToolCall     *ToolCallDelta  `json:"tool_call,omitempty"`     // 工具调用增量（tool_call_delta）/ Tool call delta (tool_call_delta)
+	Usage        *TokenUsage     `json:"usage,omitempty"`         // Token使用情况（usage）/ Token usage (usage)
+	FinishReason string          `json:"finish_reason,omitempty"` // 结束原因（finish）/ Finish reason (finish)
+	Output       *Output         `json:"output,omitempty"`        // 完整输出（done）/ Accumulated output (done)
+}
+
+// ToolCallDelta 工具调用增量，同一工具调用的多个增量具有相同的 Index
+// ID 和 Name 通常只出现在第一个增量中，Arguments 是参数 JSON 的片段，需要按顺序拼接
+// PartialArguments 是截至当前增量已生成参数的尽力解析结果，可用于在生成过程中展示参数
+// ToolCallDelta is an incremental tool call; deltas of the same call share the Index
+// ID and Name usually only appear in the first delta, and Arguments is a fragment of the argument JSON to be concatenated in order
+// PartialArguments is a best-effort parse of the arguments generated so far, useful for showing them while they stream
+type ToolCallDelta struct {
+	Index            int            `json:"index"`                       // 工具调用序号 / Tool call index
+	ID               string         `json:"id,omitempty"`                // 工具调用ID / Tool call ID
+	Name             string         `json:"name,omitempty"`              // 工具名称 / Tool name
+	Arguments        string         `json:"arguments,omitempty"`         // 参数 JSON 片段 / Argument JSON fragment
+	PartialArguments map[string]any `json:"partial_arguments,omitempty"` // 已生成参数的解析结果 / Parsed arguments generated so far
+}
+
+// StreamHandler 流式事件回调函数
+// StreamHandler is called for each streaming event
+type StreamHandler func(event StreamEvent)
+
+// EventStreamer 支持类型化流式事件的 LLM
+// 内置的所有客户端都实现了该接口；usage 和 finish 事件在流结束时各发送一次
+// EventStreamer is an LLM supporting typed streaming events
+// All built-in clients implement it; usage and finish events are sent once when the stream ends
+type EventStreamer interface {
+	CompletionStreamEvents(context.Context, *Input, StreamHandler, ...Option) (*Output, error)
+}
+
+// Handler 将字符串回调适配为流式事件回调，只转发回复文本
+// Handler adapts the string callback to a StreamHandler, forwarding answer text only
+func (f StreamOutput) Handler() StreamHandler {
+	if f == nil {
+		return nil
+	}
+	return func(event StreamEvent) {
+		if event.Type == StreamEventContent {
+			f(event.Content)
+		}
+	}
+}
+
+// CompletionStreamEvents 以类型化事件的方式执行流式对话
+// llm 未实现 EventStreamer 时退化为 CompletionStream，只能提供文本、usage 和 finish 事件
+// CompletionStreamEvents performs a streaming completion with typed events
+// LLMs that do not implement EventStreamer fall back to CompletionStream, providing only content, usage and finish events
+func CompletionStreamEvents(ctx context.Context, llm LLM, input *Input, handler StreamHandler, opts ...Option) (*Output, error) {
+	if streamer, ok := llm.(EventStreamer); ok {
+		return streamer.CompletionStreamEvents(ctx, input, handler, opts...)
+	}
+	output, err := llm.CompletionStream(ctx, input, func(content string) {
+		handler.send(StreamEvent{Type: StreamEventContent, Content: content})
+	}, opts...)
+	if output != nil {
+		handler.finish(output)
+	}
+	return output, err
+}
+
+// Stream 以迭代器的方式执行流式对话，适用于任意 LLM
+// 每个事件在被消费后才会继续读取下一个事件；提前 break 会取消底层的 HTTP 流
+// 最后一个事件为 StreamEventDone，携带累积的 *Output 以及调用错误（如有）
+// Stream performs a streaming completion as an iterator, working with any LLM
+// The next event is only read after the current one has been consumed; breaking out of the loop early
+// cancels the underlying HTTP stream
+// The last event is StreamEventDone, carrying the accumulated *Output and the call error, if any
+func Stream(ctx context.Context, llm LLM, input *Input, opts ...Option) iter.Seq2[StreamEvent, error] {
+	return func(yield func(StreamEvent, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			output *Output
+			err    error
+		)
+		events := make(chan StreamEvent)
+		go func() {
+			defer close(events)
+			output, err = CompletionStreamEvents(ctx, llm, input, func(event StreamEvent) {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+				}
+			}, opts...)
+		}()
+
+		for event := range events {
+			if !yield(event, nil) {
+				// 取消请求并等待后台 goroutine 退出 / Cancel the request and wait for the goroutine to exit
+				cancel()
+				for range events {
+				}
+				return
+			}
+		}
+		yield(StreamEvent{Type: StreamEventDone, Output: output}, err)
+	}
+}
+
+// send 发送事件，handler 为空时忽略
+// send sends the event, ignoring a nil handler
+func (h StreamHandler) send(event StreamEvent) {
+	if h != nil {
+		h(event)
+	}
+}
+
+// content 发送非空的回复文本增量
+// content sends a non-empty answer text delta
+func (h StreamHandler) content(text string) {
+	if text != "" {
+		h.send(StreamEvent{Type: StreamEventContent, Content: text})
+	}
+}
+
+// thinking 发送非空的思考文本增量
+// thinking sends a non-empty thinking text delta
+func (h StreamHandler) thinking(text string) {
+	if text != "" {
+		h.send(StreamEvent{Type: StreamEventThinking, Content: text})
+	}
+}
+
+// toolCall 发送工具调用增量
+// toolCall sends a tool call delta
+func (h StreamHandler) toolCall(delta ToolCallDelta) {
+	h.send(StreamEvent{Type: StreamEventToolCall, ToolCall: &delta})
+}
+
+// finish 在流结束时发送 usage 和 finish 事件
+// finish sends the usage and finish events at the end of the stream
+func (h StreamHandler) finish(output *Output) {
+	usage := output.TokenUsage
+	h.send(StreamEvent{Type: StreamEventUsage, Usage: &usage})
+	h.send(StreamEvent{Type: StreamEventFinish, FinishReason: output.FinishReason})
+}
+
+// ============================================================================
+// 流式中断处理 / Interrupted Streams
+// ============================================================================
+
+// streamRecorder 记录已发送的流式事件，为工具调用增量补充已解析的参数，流中断时据此构建部分输出
+// streamRecorder records the events sent so far, fills in the parsed arguments of tool call deltas,
+// and builds the partial output when a stream is interrupted
+type streamRecorder struct {
+	startAt   time.Time
+	received  bool
+	timer     tokenTimer
+	content   strings.Builder
+	thinking  strings.Builder
+	toolCalls map[int]*ToolCall
+	arguments map[int]*strings.Builder
+}
+
+// newStreamRecorder 创建流式事件记录器
+// newStreamRecorder creates a stream event recorder
+func newStreamRecorder() *streamRecorder {
+	now := time.Now()
+	return &streamRecorder{
+		startAt:   now,
+		timer:     tokenTimer{start: now},
+		toolCalls: make(map[int]*ToolCall),
+		arguments: make(map[int]*strings.Builder),
+	}
+}
+
+// wrap 返回先记录事件再转发给 handler 的回调
+// wrap returns a handler that records each event before forwarding it to handler
+func (r *streamRecorder) wrap(handler StreamHandler) StreamHandler {
+	return func(event StreamEvent) {
+		r.record(event)
+		handler.send(event)
+	}
+}
+
+// record 累积文本和工具调用增量，并将已累积参数的解析结果写入工具调用增量
+// record accumulates text and tool call deltas, storing the parsed accumulated arguments in the tool call delta
+func (r *streamRecorder) record(event StreamEvent) {
+	r.timer.observe(event)
+	switch event.Type {
+	case StreamEventContent:
+		r.content.WriteString(event.Content)
+	case StreamEventThinking:
+		r.thinking.WriteString(event.Content)
+	case StreamEventToolCall:
+		delta := event.ToolCall
+		call, ok := r.toolCalls[delta.Index]
+		if !ok {
+			call = &ToolCall{}
+			r.toolCalls[delta.Index] = call
+			r.arguments[delta.Index] = &strings.Builder{}
+		}
+		if delta.ID != "" {
+			call.ID = delta.ID
+		}
+		if delta.Name != "" {
+			call.Name = delta.Name
+		}
+		if delta.Arguments != "" {
+			r.arguments[delta.Index].WriteString(delta.Arguments)
+			delta.PartialArguments = parsePartialJSON(r.arguments[delta.Index].String())
+		}
+	default:
+		return
+	}
+	r.received = true
+}
+
+// output 根据已记录的事件构建部分输出，参数不完整的工具调用其 Arguments 为 nil
+// output builds the partial output from the recorded events; tool calls with incomplete arguments have nil Arguments
+func (r *streamRecorder) output() *Output {
+	output := &Output{
+		StartAt:  r.startAt,
+		Content:  r.content.String(),
+		Thinking: r.thinking.String(),
+		Cost:     time.Since(r.startAt),
+
+		TimeToFirstToken: r.timer.timeToFirstToken(),
+	}
+	for _, index := range slices.Sorted(maps.Keys(r.toolCalls)) {
+		call := *r.toolCalls[index]
+		call.Arguments, _ = parseToolArguments(r.arguments[index].String())
+		output.ToolCalls = append(output.ToolCalls, call)
+	}
+	return output
+}
+
+// finish 为完整输出补充首 token 延迟，并向 handler 发送 usage 和 finish 事件
+// finish fills in the time to first token of the complete output and sends the usage and finish events to handler
+func (r *streamRecorder) finish(handler StreamHandler, output *Output) {
+	output.TimeToFirstToken = r.timer.timeToFirstToken()
+	handler.finish(output)
+}
+
+// interrupt 处理中断的流：尚未收到任何内容时直接返回 err，
+// 否则返回部分输出和 *IncompleteError，并向 handler 发送 usage 和 finish 事件
+// interrupt handles an interrupted stream: err is returned as is when nothing has been received yet,
+// otherwise the partial output is returned with an *IncompleteError, and usage and finish events are sent to handler
+func (r *streamRecorder) interrupt(ctx context.Context, handler StreamHandler, err error) (*Output, error) {
+	if !r.received {
+		return nil, err
+	}
+	output := r.output()
+	output.FinishReason = string(FinishReasonError)
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		output.FinishReason = string(FinishReasonCancelled)
+	}
+	handler.finish(output)
+	return output, &IncompleteError{Output: output, Err: err}
+}
+
+// ============================================================================
+// 流式延迟 / Streaming Latency
+// ============================================================================
+
+// tokenTimer 记录生成内容（文本、思考和工具调用增量）的到达时间
+// tokenTimer records when generated content (text, thinking and tool call deltas) arrives
+type tokenTimer struct {
+	start time.Time // 调用开始时间 / Start of the call
+	first time.Time // 第一个内容增量的到达时间 / Arrival of the first content delta
+	last  time.Time // 最后一个内容增量的到达时间 / Arrival of the last content delta
+	count int       // 内容增量数 / Number of content deltas
+}
+
+// observe 记录事件，非内容事件被忽略
+// observe records the event, ignoring events without generated content
+func (t *tokenTimer) observe(event StreamEvent) {
+	if !isTokenEvent(event) {
+		return
+	}
+	now := time.Now()
+	if t.count == 0 {
+		t.first = now
+	}
+	t.last = now
+	t.count++
+}
+
+// timeToFirstToken 返回从调用开始到第一个内容增量的时间，尚未收到内容时返回 0
+// timeToFirstToken returns the time from the start of the call to the first content delta, or 0 if none has arrived
+func (t *tokenTimer) timeToFirstToken() time.Duration {
+	if t.count == 0 {
+		return 0
+	}
+	return t.first.Sub(t.start)
+}
+
+// interTokenLatency 返回相邻内容增量的平均间隔，少于两个增量时返回 0
+// interTokenLatency returns the mean gap between consecutive content deltas, or 0 with fewer than two deltas
+func (t *tokenTimer) interTokenLatency() time.Duration {
+	if t.count < 2 {
+		return 0
+	}
+	return t.last.Sub(t.first) / time.Duration(t.count-1)
+}
+
+// isTokenEvent 判断事件是否携带模型生成的内容
+// isTokenEvent reports whether the event carries generated content
+func isTokenEvent(event StreamEvent) bool {
+	switch event.Type {
+	case StreamEventContent, StreamEventThinking, StreamEventToolCall:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,306 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSSEServer 创建按顺序返回 SSE 事件的测试服务器
+// newSSEServer creates a test server replying with the given SSE events
+func newSSEServer(t *testing.T, events ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func openAIChunk(delta string, extra string) string {
+	return `data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":` + delta + extra + `}]}` + "\n\n"
+}
+
+func TestOpenAI_CompletionStreamEvents(t *testing.T) {
+	server := newSSEServer(t,
+		openAIChunk(`{"role":"assistant","content":"你好"}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"query_weather","arguments":"{\"city\":"}}]}`, ""),
+		openAIChunk(`{"tool_calls":[{"index":0,"function":{"arguments":"\"北京\"}"}}]}`, `,"finish_reason":"tool_calls"`),
+		`data: {"id":"1","object":"chat.completion.chunk","created":1,"model":"m","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`+"\n\n",
+		"data: [DONE]\n\n",
+	)
+
+	var events []StreamEvent
+	client := CreateOpenAI(URL(server.URL), APIKey("test"))
+	output, err := client.CompletionStreamEvents(context.Background(), &Input{Model: "m", Messages: []Message{UserMessage("hi")}}, func(event StreamEvent) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatalf("CompletionStreamEvents() error = %v", err)
+	}
+
+	var types []string
+	for _, event := range events {
+		types = append(types, string(event.Type))
+	}
+	want := "content,tool_call_delta,tool_call_delta,usage,finish"
+	if strings.Join(types, ",") != want {
+		t.Fatalf("Expected events %s, got %s", want, strings.Join(types, ","))
+	}
+	if events[1].ToolCall.ID != "call_1" || events[1].ToolCall.Name != "query_weather" || events[2].ToolCall.Arguments != `"北京"}` {
+		t.Errorf("Unexpected tool call deltas: %+v %+v", events[1].ToolCall, events[2].ToolCall)
+	}
+	if len(events[1].ToolCall.PartialArguments) != 0 || events[2].ToolCall.PartialArguments["city"] != "北京" {
+		t.Errorf("Unexpected partial arguments: %v %v", events[1].ToolCall.PartialArguments, events[2].ToolCall.PartialArguments)
+	}
+	if events[3].Usage.TotalTokens != 15 || events[4].FinishReason != "tool_calls" {
+		t.Errorf("Unexpected usage/finish events: %+v %+v", events[3], events[4])
+	}
+	if output.Content != "你好" || len(output.ToolCalls) != 1 || output.ToolCalls[0].Arguments["city"] != "北京" {
+		t.Errorf("Unexpected output: %+v", output)
+	}
+	if output.TimeToFirstToken <= 0 || output.TimeToFirstToken > output.Cost {
+		t.Errorf("Expected time to first token within the call duration, got %v (cost %v)", output.TimeToFirstToken, output.Cost)
+	}
+
+	// 字符串回调只收到回复文本
+	var chunks []string
+	if _, err := client.CompletionStream(context.Background(), &Input{Model: "m"}, func(content string) { chunks = append(chunks, content) }); err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	if strings.Join(chunks, "") != "你好" {
+		t.Errorf("Expected only answer text, got %q", chunks)
+	}
+}
+
+func TestStreamOutput_Handler(t *testing.T) {
+	var chunks []string
+	handler := StreamOutput(func(content string) { chunks = append(chunks, content) }).Handler()
+	handler(StreamEvent{Type: StreamEventThinking, Content: "思考"})
+	handler(StreamEvent{Type: StreamEventContent, Content: "回答"})
+	handler(StreamEvent{Type: StreamEventFinish, FinishReason: "STOP"})
+	if strings.Join(chunks, ",") != "回答" {
+		t.Errorf("Expected only content to be forwarded, got %v", chunks)
+	}
+
+	if StreamOutput(nil).Handler() != nil {
+		t.Error("Expected nil handler for nil callback")
+	}
+}
+
+func TestCompletionStreamEvents_Fallback(t *testing.T) {
+	llm := &mockLLM{responses: []mockResponse{{output: &Output{
+		Content:      "回答",
+		FinishReason: string(FinishReasonStop),
+		TokenUsage:   TokenUsage{TotalTokens: 3},
+	}}}}
+
+	var events []StreamEvent
+	output, err := CompletionStreamEvents(context.Background(), llm, &Input{}, func(event StreamEvent) { events = append(events, event) })
+	if err != nil || output.Content != "回答" {
+		t.Fatalf("CompletionStreamEvents() = %v, %v", output, err)
+	}
+	if len(events) != 3 || events[0].Content != "回答" || events[1].Usage.TotalTokens != 3 || events[2].FinishReason != "STOP" {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
+
+func TestGemini_CompletionStreamEvents(t *testing.T) {
+	server := newSSEServer(t,
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"先想一想","thought":true}]}}]}`+"\n\n",
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"晴天"}]}}]}`+"\n\n",
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"。"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"thoughtsTokenCount":3,"totalTokenCount":9}}`+"\n\n",
+	)
+
+	g, err := newGemini(context.Background(), URL(server.URL), APIKey("test"))
+	if err != nil {
+		t.Fatalf("newGemini() error = %v", err)
+	}
+
+	var thinking, content strings.Builder
+	var finish string
+	output, err := g.CompletionStreamEvents(context.Background(), &Input{Messages: []Message{UserMessage("天气")}}, func(event StreamEvent) {
+		switch event.Type {
+		case StreamEventThinking:
+			thinking.WriteString(event.Content)
+		case StreamEventContent:
+			content.WriteString(event.Content)
+		case StreamEventFinish:
+			finish = event.FinishReason
+		}
+	})
+	if err != nil {
+		t.Fatalf("CompletionStreamEvents() error = %v", err)
+	}
+	if thinking.String() != "先想一想" || content.String() != "晴天。" {
+		t.Errorf("Expected thinking and content to be separated, got %q / %q", thinking.String(), content.String())
+	}
+	if output.Content != "晴天。" || output.Thinking != "先想一想" || finish != string(FinishReasonStop) {
+		t.Errorf("Unexpected output: %+v, finish %s", output, finish)
+	}
+}
+
+func TestStream(t *testing.T) {
+	llm := &mockLLM{responses: []mockResponse{{output: &Output{Content: "回答", FinishReason: string(FinishReasonStop)}}}}
+
+	var types []string
+	var output *Output
+	for event, err := range Stream(context.Background(), llm, &Input{}) {
+		if err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+		types = append(types, string(event.Type))
+		if event.Type == StreamEventDone {
+			output = event.Output
+		}
+	}
+	if strings.Join(types, ",") != "content,usage,finish,done" {
+		t.Errorf("Unexpected events: %v", types)
+	}
+	if output == nil || output.Content != "回答" {
+		t.Errorf("Expected done event to carry the output, got %+v", output)
+	}
+
+	apiErr := fmt.Errorf("api error")
+	for event, err := range Stream(context.Background(), &mockLLM{responses: []mockResponse{{err: apiErr}}}, &Input{}) {
+		if event.Type != StreamEventDone || err != apiErr {
+			t.Errorf("Expected a single done event with the error, got %+v, %v", event, err)
+		}
+	}
+}
+
+func TestStream_BreakCancelsRequest(t *testing.T) {
+	canceled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, openAIChunk(`{"role":"assistant","content":"你好"}`, ""))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(canceled)
+	}))
+	defer server.Close()
+
+	client := CreateOpenAI(URL(server.URL), APIKey("test"))
+	for event, err := range Stream(context.Background(), client, &Input{Model: "m"}) {
+		if err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+		if event.Type == StreamEventContent {
+			break
+		}
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the HTTP stream to be canceled after break")
+	}
+}
+
+func TestStream_ConnectionDropped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, openAIChunk(`{"role":"assistant","content":"你好"}`, ""))
+		fmt.Fprint(w, openAIChunk(`{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"query_weather","arguments":"{\"city\":"}}]}`, ""))
+		w.(http.Flusher).Flush()
+		// 不结束分块编码直接断开连接 / Drop the connection without terminating the chunked body
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}))
+	defer server.Close()
+
+	var finish string
+	output, err := CreateOpenAI(URL(server.URL), APIKey("test")).CompletionStreamEvents(context.Background(), &Input{Model: "m"}, func(event StreamEvent) {
+		if event.Type == StreamEventFinish {
+			finish = event.FinishReason
+		}
+	})
+	var incomplete *IncompleteError
+	if !errors.As(err, &incomplete) || incomplete.Output != output {
+		t.Fatalf("Expected IncompleteError carrying the output, got %v", err)
+	}
+	if output.Content != "你好" || output.FinishReason != string(FinishReasonError) || finish != string(FinishReasonError) {
+		t.Errorf("Unexpected partial output: %+v, finish event %q", output, finish)
+	}
+	if len(output.ToolCalls) != 1 || output.ToolCalls[0].ID != "call_1" || output.ToolCalls[0].Arguments != nil {
+		t.Errorf("Expected the incomplete tool call without arguments, got %+v", output.ToolCalls)
+	}
+}
+
+func TestStream_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, openAIChunk(`{"role":"assistant","content":"你好"}`, ""))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	output, err := CreateOpenAI(URL(server.URL), APIKey("test")).CompletionStreamEvents(ctx, &Input{Model: "m"}, func(event StreamEvent) {
+		if event.Type == StreamEventContent {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	var incomplete *IncompleteError
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("Expected IncompleteError, got %T", err)
+	}
+	if output == nil || output.Content != "你好" || output.FinishReason != string(FinishReasonCancelled) {
+		t.Errorf("Unexpected partial output: %+v", output)
+	}
+}
+
+func TestAnthropic_CompletionStreamEvents_AccumulateError(t *testing.T) {
+	// 没有 content_block_start 的增量会导致累积失败，应返回错误而不是 panic
+	server := newSSEServer(t,
+		"event: message_start\ndata: "+`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}`+"\n\n",
+		"event: content_block_delta\ndata: "+`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"你好"}}`+"\n\n",
+	)
+	_, err := CreateAnthropic(URL(server.URL), APIKey("test")).CompletionStreamEvents(context.Background(), &Input{Model: "m"}, nil)
+	var llmErr *LLMError
+	if !errors.As(err, &llmErr) || llmErr.Code != "STREAM_ERROR" {
+		t.Fatalf("Expected STREAM_ERROR, got %v", err)
+	}
+}
+
+func TestAnthropic_CompletionStreamEvents_PartialArguments(t *testing.T) {
+	sse := func(event, data string) string { return "event: " + event + "\ndata: " + data + "\n\n" }
+	server := newSSEServer(t,
+		sse("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"m","content":[],"usage":{"input_tokens":1,"output_tokens":0}}}`),
+		sse("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"read_file","input":{}}}`),
+		sse("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"path\": \"/etc/ho"}}`),
+		sse("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"sts\"}"}}`),
+		sse("content_block_stop", `{"type":"content_block_stop","index":0}`),
+		sse("message_delta", `{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`),
+		sse("message_stop", `{"type":"message_stop"}`),
+	)
+
+	var partial []any
+	output, err := CreateAnthropic(URL(server.URL), APIKey("test")).CompletionStreamEvents(context.Background(), &Input{Model: "m"}, func(event StreamEvent) {
+		if event.Type == StreamEventToolCall && event.ToolCall.Arguments != "" {
+			partial = append(partial, event.ToolCall.PartialArguments["path"])
+		}
+	})
+	if err != nil {
+		t.Fatalf("CompletionStreamEvents() error = %v", err)
+	}
+	if len(partial) != 2 || partial[0] != "/etc/ho" || partial[1] != "/etc/hosts" {
+		t.Errorf("Expected growing partial paths, got %v", partial)
+	}
+	if len(output.ToolCalls) != 1 || output.ToolCalls[0].Arguments["path"] != "/etc/hosts" {
+		t.Errorf("Unexpected tool calls: %+v", output.ToolCalls)
+	}
+}
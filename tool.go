@@ -2,8 +2,15 @@ package OpenLLM
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
 
+	anthropic "github.com/anthropics/anthropic-sdk-go"
 	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"google.golang.org/genai"
 )
 
 // ============================================================================
@@ -26,6 +33,57 @@ func (t *Tool) ToOpenAITool() (openai.ChatCompletionToolUnionParam, error) {
 	}), nil
 }
 
+// ToOpenAIResponsesTool 将Tool转换为OpenAI Responses API ToolUnionParam
+// ToOpenAIResponsesTool converts Tool to OpenAI Responses API ToolUnionParam
+func (t *Tool) ToOpenAIResponsesTool() (responses.ToolUnionParam, error) {
+	if err := t.Validate(); err != nil {
+		return responses.ToolUnionParam{}, fmt.Errorf("工具验证失败: %w", err)
+	}
+
+	tool := responses.ToolParamOfFunction(t.Name, t.Parameters.ToOpenAIParameters(), false)
+	tool.OfFunction.Description = openai.String(t.Description)
+	return tool, nil
+}
+
+// ToAnthropicTool 将Tool转换为Anthropic ToolUnionParam
+// ToAnthropicTool converts Tool to Anthropic ToolUnionParam
+func (t *Tool) ToAnthropicTool() (anthropic.ToolUnionParam, error) {
+	if err := t.Validate(); err != nil {
+		return anthropic.ToolUnionParam{}, fmt.Errorf("工具验证失败: %w", err)
+	}
+
+	params := t.Parameters.ToOpenAIParameters()
+	inputSchema := anthropic.ToolInputSchemaParam{
+		Properties: params["properties"],
+		Required:   t.Parameters.Required,
+	}
+
+	tool := anthropic.ToolUnionParamOfTool(inputSchema, t.Name)
+	tool.OfTool.Description = anthropic.String(t.Description)
+	return tool, nil
+}
+
+// ToGeminiFunctionDeclaration 将Tool转换为Gemini FunctionDeclaration
+// ToGeminiFunctionDeclaration converts Tool to Gemini FunctionDeclaration
+func (t *Tool) ToGeminiFunctionDeclaration() (*genai.FunctionDeclaration, error) {
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("工具验证失败: %w", err)
+	}
+
+	declaration := &genai.FunctionDeclaration{
+		Name:        t.Name,
+		Description: t.Description,
+	}
+	// Gemini Schema 无法表示 map 类型（AdditionalProperties），此时通过 ParametersJsonSchema 发送完整的 JSON Schema
+	// Gemini Schema cannot express map types (AdditionalProperties), so the full JSON Schema is sent through ParametersJsonSchema
+	if t.Parameters.SupportsOpenAIStrict() {
+		declaration.Parameters = t.Parameters.ToGeminiSchema()
+	} else {
+		declaration.ParametersJsonSchema = map[string]any(t.Parameters.ToOpenAIParameters())
+	}
+	return declaration, nil
+}
+
 // Validate 验证Tool定义是否有效
 // Validate checks if the Tool definition is valid
 func (t *Tool) Validate() error {
@@ -92,14 +150,145 @@ func (s *JSONSchema) ToOpenAIParameters() openai.FunctionParameters {
 		params["maximum"] = *s.Maximum
 	}
 
+	if s.Format != "" {
+		params["format"] = s.Format
+	}
+
+	if s.AdditionalProperties != nil {
+		params["additionalProperties"] = s.AdditionalProperties.toOpenAIProperty()
+	}
+
 	return params
 }
 
+// ToGeminiSchema 将JSONSchema转换为Gemini Schema
+// Gemini Schema 不支持 AdditionalProperties，含 map 的 Schema 应改用 ToOpenAIParameters 生成的 JSON Schema
+// ToGeminiSchema converts JSONSchema to Gemini Schema
+// Gemini Schema has no AdditionalProperties, so schemas containing maps should use the JSON Schema from ToOpenAIParameters
+func (s *JSONSchema) ToGeminiSchema() *genai.Schema {
+	schema := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(s.Type)),
+		Description: s.Description,
+		Required:    s.Required,
+		Default:     s.Default,
+		Minimum:     s.Minimum,
+		Maximum:     s.Maximum,
+		Format:      s.Format,
+	}
+
+	if len(s.Properties) > 0 {
+		schema.Properties = make(map[string]*genai.Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			schema.Properties[name] = prop.ToGeminiSchema()
+		}
+	}
+
+	if s.Items != nil {
+		schema.Items = s.Items.ToGeminiSchema()
+	}
+
+	// Gemini 的枚举值只支持字符串
+	for _, v := range s.Enum {
+		schema.Enum = append(schema.Enum, fmt.Sprint(v))
+	}
+
+	return schema
+}
+
+// ToOpenAIStrictSchema 将JSONSchema转换为OpenAI严格模式（strict）要求的Schema
+// 严格模式要求所有属性都列入 required 且禁止额外属性，非必填属性转换为可为 null 的类型
+// ToOpenAIStrictSchema converts JSONSchema to the schema required by OpenAI strict mode
+// Strict mode requires every property to be listed as required and forbids additional properties,
+// so optional properties are converted to nullable types
+// 严格模式无法表示 map 类型，含 map 的 Schema 应先用 SupportsOpenAIStrict 判断
+// Strict mode cannot express map types, check schemas containing maps with SupportsOpenAIStrict first
+func (s *JSONSchema) ToOpenAIStrictSchema() map[string]any {
+	return s.toOpenAIStrictProperty(false)
+}
+
+// SupportsOpenAIStrict 判断Schema能否用OpenAI严格模式表示：严格模式禁止额外属性，
+// 任意键的 map（AdditionalProperties）只能为空对象
+// SupportsOpenAIStrict reports whether the schema can be expressed in OpenAI strict mode: strict mode forbids
+// additional properties, so maps with arbitrary keys (AdditionalProperties) could only ever be empty
+func (s *JSONSchema) SupportsOpenAIStrict() bool {
+	if s.AdditionalProperties != nil {
+		return false
+	}
+	for _, p := range s.Properties {
+		if !p.SupportsOpenAIStrict() {
+			return false
+		}
+	}
+	return s.Items == nil || s.Items.SupportsOpenAIStrict()
+}
+
+// toOpenAIStrictProperty 将JSONSchema转换为OpenAI严格模式属性格式（内部方法）
+// toOpenAIStrictProperty converts JSONSchema to OpenAI strict mode property format (internal method)
+func (s *JSONSchema) toOpenAIStrictProperty(nullable bool) map[string]any {
+	prop := map[string]any{}
+	// 未指定类型时接受任意值（包括 null），不输出 type
+	switch {
+	case s.Type == "":
+	case nullable:
+		prop["type"] = []string{s.Type, "null"}
+	default:
+		prop["type"] = s.Type
+	}
+
+	if s.Description != "" {
+		prop["description"] = s.Description
+	}
+
+	if s.Type == "object" {
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		properties := make(map[string]any, len(names))
+		for _, name := range names {
+			properties[name] = s.Properties[name].toOpenAIStrictProperty(!slices.Contains(s.Required, name))
+		}
+		prop["properties"] = properties
+		prop["required"] = names
+		prop["additionalProperties"] = false
+	}
+
+	if s.Items != nil {
+		prop["items"] = s.Items.toOpenAIStrictProperty(false)
+	}
+
+	if len(s.Enum) > 0 {
+		enum := s.Enum
+		if nullable {
+			enum = append(slices.Clone(enum), nil)
+		}
+		prop["enum"] = enum
+	}
+
+	if s.Minimum != nil {
+		prop["minimum"] = *s.Minimum
+	}
+
+	if s.Maximum != nil {
+		prop["maximum"] = *s.Maximum
+	}
+
+	if s.Format != "" {
+		prop["format"] = s.Format
+	}
+
+	return prop
+}
+
 // toOpenAIProperty 将JSONSchema转换为OpenAI属性格式（内部方法）
 // toOpenAIProperty converts JSONSchema to OpenAI property format (internal method)
 func (s *JSONSchema) toOpenAIProperty() map[string]any {
-	prop := map[string]any{
-		"type": s.Type,
+	prop := map[string]any{}
+	// 未指定类型时接受任意值，不输出 type
+	if s.Type != "" {
+		prop["type"] = s.Type
 	}
 
 	if s.Description != "" {
@@ -138,6 +327,14 @@ func (s *JSONSchema) toOpenAIProperty() map[string]any {
 		prop["maximum"] = *s.Maximum
 	}
 
+	if s.Format != "" {
+		prop["format"] = s.Format
+	}
+
+	if s.AdditionalProperties != nil {
+		prop["additionalProperties"] = s.AdditionalProperties.toOpenAIProperty()
+	}
+
 	return prop
 }
 
@@ -170,5 +367,101 @@ func (s *JSONSchema) Validate() error {
 		}
 	}
 
+	if s.AdditionalProperties != nil {
+		if err := s.AdditionalProperties.Validate(); err != nil {
+			return fmt.Errorf("additionalProperties验证失败: %w", err)
+		}
+	}
+
 	return nil
 }
+
+// ValidateValue 验证JSON解码后的值是否符合Schema，不符合时返回 *SchemaValidationError
+// 非必填属性的值允许为 null
+// ValidateValue checks that a JSON-decoded value conforms to the schema, returning *SchemaValidationError otherwise
+// Optional properties may be null
+func (s *JSONSchema) ValidateValue(value any) error {
+	return s.validateValue("$", value)
+}
+
+// validateValue 按路径递归验证值（内部方法）
+// validateValue recursively validates the value at the given path (internal method)
+func (s *JSONSchema) validateValue(path string, value any) error {
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return schemaTypeError(path, s.Type, value)
+		}
+		for _, required := range s.Required {
+			if v, exists := obj[required]; !exists || v == nil {
+				return &SchemaValidationError{Path: path + "." + required, Message: "缺少必填字段"}
+			}
+		}
+
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, defined := s.Properties[name]
+			if !defined {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil || obj[name] == nil {
+				continue
+			}
+			if err := prop.validateValue(path+"."+name, obj[name]); err != nil {
+				return err
+			}
+		}
+
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return schemaTypeError(path, s.Type, value)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := s.Items.validateValue(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+
+	case "string":
+		if _, ok := value.(string); !ok {
+			return schemaTypeError(path, s.Type, value)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return schemaTypeError(path, s.Type, value)
+		}
+
+	case "number", "integer":
+		number, ok := value.(float64)
+		if !ok || (s.Type == "integer" && number != math.Trunc(number)) {
+			return schemaTypeError(path, s.Type, value)
+		}
+		if s.Minimum != nil && number < *s.Minimum {
+			return &SchemaValidationError{Path: path, Message: fmt.Sprintf("值 %v 小于最小值 %v", number, *s.Minimum)}
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			return &SchemaValidationError{Path: path, Message: fmt.Sprintf("值 %v 大于最大值 %v", number, *s.Maximum)}
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		return &SchemaValidationError{Path: path, Message: fmt.Sprintf("值 %v 不在枚举 %v 中", value, s.Enum)}
+	}
+
+	return nil
+}
+
+// schemaTypeError 创建类型不匹配的验证错误
+// schemaTypeError creates a type mismatch validation error
+func schemaTypeError(path, want string, value any) *SchemaValidationError {
+	return &SchemaValidationError{Path: path, Message: fmt.Sprintf("类型应为 %s，实际为 %T", want, value)}
+}
@@ -0,0 +1,155 @@
+package OpenLLM
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ============================================================================
+// 工具注册表 / Tool Registry
+// ============================================================================
+
+// ToolHandler 工具处理函数，接收模型给出的参数，返回发送给模型的工具结果
+// ToolHandler handles a tool call, receiving the model's arguments and returning the result sent back to the model
+type ToolHandler func(ctx context.Context, arguments map[string]any) (string, error)
+
+// ToolRegistry 工具注册表，将工具定义与处理函数绑定并负责执行工具调用
+// ToolRegistry binds tool definitions to handlers and executes tool calls
+type ToolRegistry struct {
+	mu       sync.RWMutex
+	tools    []Tool
+	handlers map[string]ToolHandler
+	tracer   *tracer // 工具执行追踪，nil 表示未启用 / Tool execution tracing, nil when disabled
+}
+
+// NewToolRegistry 创建工具注册表
+// NewToolRegistry creates a tool registry
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{handlers: make(map[string]ToolHandler)}
+}
+
+// Register 注册工具及其处理函数，工具名称必须唯一
+// Register registers a tool and its handler; tool names must be unique
+func (r *ToolRegistry) Register(tool Tool, handler ToolHandler) error {
+	if err := tool.Validate(); err != nil {
+		return fmt.Errorf("工具验证失败: %w", err)
+	}
+	if handler == nil {
+		return fmt.Errorf("工具 %s 的处理函数不能为空", tool.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.handlers[tool.Name]; exists {
+		return fmt.Errorf("工具 %s 已注册", tool.Name)
+	}
+	r.tools = append(r.tools, tool)
+	r.handlers[tool.Name] = handler
+	return nil
+}
+
+// RegisterFunc 注册类型化的工具处理函数，工具参数 Schema 由 Args 通过反射生成
+// 参数会先按 Schema 校验再解码为 Args；Result 为字符串时直接返回，否则编码为 JSON
+// RegisterFunc registers a typed tool handler whose parameter schema is derived from Args by reflection
+// Arguments are validated against the schema before being decoded into Args; a string Result is
+// returned as is, any other Result is encoded as JSON
+func RegisterFunc[Args, Result any](r *ToolRegistry, name, description string, fn func(context.Context, Args) (Result, error)) error {
+	tool, err := NewTool[Args](name, description)
+	if err != nil {
+		return err
+	}
+	parameters := tool.Parameters
+
+	return r.Register(*tool, func(ctx context.Context, arguments map[string]any) (string, error) {
+		raw, err := json.Marshal(arguments)
+		if err != nil {
+			return "", fmt.Errorf("编码参数失败: %w", err)
+		}
+		// 经过一次 JSON 往返，使数值等类型与 JSON 解码结果一致后再校验
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return "", fmt.Errorf("解码参数失败: %w", err)
+		}
+		if value == nil {
+			value = map[string]any{}
+		}
+		if err := parameters.ValidateValue(value); err != nil {
+			return "", fmt.Errorf("参数不符合Schema: %w", err)
+		}
+
+		var args Args
+		if err := json.Unmarshal(raw, &args); err != nil {
+			return "", fmt.Errorf("解码参数失败: %w", err)
+		}
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		content, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("编码结果失败: %w", err)
+		}
+		return string(content), nil
+	})
+}
+
+// Tools 返回已注册的工具定义（按注册顺序），可直接用作 Input.Tools
+// Tools returns the registered tool definitions in registration order, ready for Input.Tools
+func (r *ToolRegistry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tools := make([]Tool, len(r.tools))
+	copy(tools, r.tools)
+	return tools
+}
+
+// Execute 执行单个工具调用，结果或错误都会转换为带有对应 ToolCallID 的工具消息
+// Execute runs a single tool call; both results and errors become a tool message with the matching ToolCallID
+func (r *ToolRegistry) Execute(ctx context.Context, call ToolCall) Message {
+	r.mu.RLock()
+	t := r.tracer
+	r.mu.RUnlock()
+
+	ctx, span := t.startTool(ctx, call)
+	content, err := r.execute(ctx, call)
+	t.endTool(span, content, err)
+	if err != nil {
+		// 错误信息返回给模型，由模型决定重试或换一种方式回答
+		return ToolErrorMessage(fmt.Sprintf("工具 %s 执行失败: %v", call.Name, err), call.ID)
+	}
+	return ToolMessage(content, call.ID)
+}
+
+// ExecuteAll 按顺序执行多个工具调用，返回对应的工具消息
+// ExecuteAll runs the tool calls in order and returns the corresponding tool messages
+func (r *ToolRegistry) ExecuteAll(ctx context.Context, calls []ToolCall) []Message {
+	messages := make([]Message, 0, len(calls))
+	for _, call := range calls {
+		messages = append(messages, r.Execute(ctx, call))
+	}
+	return messages
+}
+
+// execute 查找并运行工具处理函数，处理函数的 panic 会转换为错误
+// execute looks up and runs the tool handler, converting handler panics to errors
+func (r *ToolRegistry) execute(ctx context.Context, call ToolCall) (content string, err error) {
+	r.mu.RLock()
+	handler, ok := r.handlers[call.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("未注册的工具")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ctx, call.Arguments)
+}
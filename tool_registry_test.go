@@ -0,0 +1,120 @@
+package OpenLLM
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type weatherArgs struct {
+	City string `json:"city" jsonschema:"description=城市名称"`
+	Days int    `json:"days,omitempty" jsonschema:"minimum=1,maximum=7"`
+}
+
+type weatherResult struct {
+	City      string `json:"city"`
+	Condition string `json:"condition"`
+}
+
+func newWeatherRegistry(t *testing.T) *ToolRegistry {
+	t.Helper()
+	registry := NewToolRegistry()
+	err := RegisterFunc(registry, "query_weather", "查询天气", func(ctx context.Context, args weatherArgs) (weatherResult, error) {
+		if args.City == "火星" {
+			return weatherResult{}, errors.New("不支持的城市")
+		}
+		return weatherResult{City: args.City, Condition: "晴"}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterFunc() error = %v", err)
+	}
+	err = registry.Register(Tool{
+		Name:        "panic",
+		Description: "总是panic",
+		Parameters:  &JSONSchema{Type: "object"},
+	}, func(ctx context.Context, arguments map[string]any) (string, error) {
+		panic("boom")
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return registry
+}
+
+func TestToolRegistry_Execute(t *testing.T) {
+	registry := newWeatherRegistry(t)
+
+	tests := []struct {
+		name        string
+		call        ToolCall
+		wantContent string
+	}{
+		{
+			name:        "success",
+			call:        ToolCall{ID: "call_1", Name: "query_weather", Arguments: map[string]any{"city": "北京"}},
+			wantContent: `{"city":"北京","condition":"晴"}`,
+		},
+		{
+			name:        "handler error",
+			call:        ToolCall{ID: "call_2", Name: "query_weather", Arguments: map[string]any{"city": "火星"}},
+			wantContent: "不支持的城市",
+		},
+		{
+			name:        "schema violation",
+			call:        ToolCall{ID: "call_3", Name: "query_weather", Arguments: map[string]any{"city": "北京", "days": 30}},
+			wantContent: "$.days",
+		},
+		{
+			name:        "missing arguments",
+			call:        ToolCall{ID: "call_4", Name: "query_weather"},
+			wantContent: "$.city",
+		},
+		{
+			name:        "unknown tool",
+			call:        ToolCall{ID: "call_5", Name: "unknown"},
+			wantContent: "未注册的工具",
+		},
+		{
+			name:        "panic",
+			call:        ToolCall{ID: "call_6", Name: "panic"},
+			wantContent: "boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := registry.Execute(context.Background(), tt.call)
+			if msg.Role != RoleTool || msg.ToolCallID != tt.call.ID {
+				t.Errorf("Expected tool message for %s, got %+v", tt.call.ID, msg)
+			}
+			if !strings.Contains(msg.Content, tt.wantContent) {
+				t.Errorf("Expected content containing %q, got %q", tt.wantContent, msg.Content)
+			}
+		})
+	}
+}
+
+func TestToolRegistry_Tools(t *testing.T) {
+	registry := newWeatherRegistry(t)
+
+	tools := registry.Tools()
+	if len(tools) != 2 || tools[0].Name != "query_weather" || tools[1].Name != "panic" {
+		t.Fatalf("Expected tools in registration order, got %+v", tools)
+	}
+	if _, err := CreateOpenAI(APIKey("test")).GenerateOpenAIChatCompletionNewParams(&Input{Tools: tools}); err != nil {
+		t.Errorf("Expected registry tools to convert, got %v", err)
+	}
+
+	if err := registry.Register(tools[0], func(ctx context.Context, arguments map[string]any) (string, error) { return "", nil }); err == nil {
+		t.Error("Expected error for duplicate tool")
+	}
+
+	messages := registry.ExecuteAll(context.Background(), []ToolCall{
+		{ID: "call_1", Name: "query_weather", Arguments: map[string]any{"city": "北京"}},
+		{ID: "call_2", Name: "query_weather", Arguments: map[string]any{"city": "上海"}},
+	})
+	if len(messages) != 2 || messages[0].ToolCallID != "call_1" || messages[1].ToolCallID != "call_2" {
+		t.Errorf("Expected ordered tool messages, got %+v", messages)
+	}
+}
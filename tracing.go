@@ -0,0 +1,362 @@
+package OpenLLM
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.36.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ============================================================================
+// 链路追踪 / Tracing
+// ============================================================================
+
+// tracerName 追踪器的 instrumentation 名称
+// tracerName is the instrumentation name of the tracer
+const tracerName = "github.com/golang-io/OpenLLM"
+
+// 语义约定 v1.36.0 尚未定义的属性，名称与后续版本的 GenAI 约定保持一致
+// Attributes not defined by semantic conventions v1.36.0, named after later versions of the GenAI conventions
+const (
+	genAIInputMessagesKey      = attribute.Key("gen_ai.input.messages")               // 输入消息（JSON）/ Input messages (JSON)
+	genAIOutputMessagesKey     = attribute.Key("gen_ai.output.messages")              // 输出消息（JSON）/ Output messages (JSON)
+	genAIToolCallArgumentsKey  = attribute.Key("gen_ai.tool.call.arguments")          // 工具调用参数（JSON）/ Tool call arguments (JSON)
+	genAIToolCallResultKey     = attribute.Key("gen_ai.tool.call.result")             // 工具调用结果 / Tool call result
+	genAIUsageCachedTokensKey  = attribute.Key("gen_ai.usage.cached_tokens")          // 命中缓存的输入 token 数 / Cached input tokens
+	genAITimeToFirstTokenKey   = attribute.Key("gen_ai.response.time_to_first_token") // 首个 token 的延迟（秒）/ Time to first token in seconds
+	genAIRequestStreamKey      = attribute.Key("gen_ai.request.stream")               // 是否流式调用 / Whether the call is streaming
+	genAIResponseIncompleteKey = attribute.Key("gen_ai.response.incomplete")          // 流式响应是否中断 / Whether the stream was interrupted
+)
+
+// TracingOptions 链路追踪配置选项
+// TracingOptions defines configuration options for tracing
+type TracingOptions struct {
+	TracerProvider trace.TracerProvider // 追踪器提供者，默认为 otel.GetTracerProvider() / Tracer provider, defaults to otel.GetTracerProvider()
+	CaptureContent bool                 // 是否记录提示词、回复和工具参数结果 / Whether prompts, completions and tool arguments/results are recorded
+}
+
+// TracingOption 链路追踪配置函数类型
+// TracingOption is a function type for configuring TracingOptions
+type TracingOption func(*TracingOptions)
+
+// TracerProvider 设置创建 span 使用的追踪器提供者
+// TracerProvider sets the tracer provider used to create spans
+func TracerProvider(provider trace.TracerProvider) TracingOption {
+	return func(options *TracingOptions) {
+		options.TracerProvider = provider
+	}
+}
+
+// CaptureContent 记录提示词、回复以及工具参数和结果，可能包含敏感数据，默认关闭
+// CaptureContent records prompts, completions and tool arguments and results; they may contain sensitive data,
+// so it is disabled by default
+func CaptureContent() TracingOption {
+	return func(options *TracingOptions) {
+		options.CaptureContent = true
+	}
+}
+
+// tracer 按 GenAI 语义约定创建 span
+// tracer creates spans following the GenAI semantic conventions
+type tracer struct {
+	tracer         trace.Tracer
+	captureContent bool
+}
+
+// newTracer 创建追踪器
+// newTracer creates a tracer
+func newTracer(opts ...TracingOption) *tracer {
+	options := TracingOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	if options.TracerProvider == nil {
+		options.TracerProvider = otel.GetTracerProvider()
+	}
+	return &tracer{
+		tracer:         options.TracerProvider.Tracer(tracerName),
+		captureContent: options.CaptureContent,
+	}
+}
+
+// Tracing 返回链路追踪中间件，每次 Completion 和 CompletionStream 调用创建一个 span
+// span 记录提供商、模型、请求参数、token 使用量、结束原因、首 token 延迟和错误码
+// Tracing returns a tracing middleware creating a span for every Completion and CompletionStream call
+// The span records the provider, model, request parameters, token usage, finish reason, time to first token
+// and error code
+func Tracing(opts ...TracingOption) Middleware {
+	t := newTracer(opts...)
+	return func(next LLM) LLM {
+		info := providerInfoOf(next)
+		return Interceptor{
+			Completion: func(ctx context.Context, input *Input, call CompletionFunc, opts ...Option) (*Output, error) {
+				ctx, span := t.startCompletion(ctx, info, input, false, opts)
+				defer span.End()
+
+				output, err := call(ctx, input, opts...)
+				t.endCompletion(span, output, err)
+				return output, err
+			},
+			Stream: func(ctx context.Context, input *Input, handler StreamHandler, call StreamFunc, opts ...Option) (*Output, error) {
+				ctx, span := t.startCompletion(ctx, info, input, true, opts)
+				defer span.End()
+
+				timer := tokenTimer{start: time.Now()}
+				output, err := call(ctx, input, func(event StreamEvent) {
+					timer.observe(event)
+					handler.send(event)
+				}, opts...)
+				if ttft := timer.timeToFirstToken(); ttft > 0 {
+					span.SetAttributes(genAITimeToFirstTokenKey.Float64(ttft.Seconds()))
+				}
+				t.endCompletion(span, output, err)
+				return output, err
+			},
+		}.Middleware()(next)
+	}
+}
+
+// startCompletion 创建模型调用 span 并记录请求属性
+// startCompletion starts a model call span and records the request attributes
+func (t *tracer) startCompletion(ctx context.Context, info ProviderInfo, input *Input, stream bool, opts []Option) (context.Context, trace.Span) {
+	model, options := requestModel(info, input, opts)
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameChat,
+		genAISystem(info.Type),
+		semconv.GenAIRequestModel(model),
+		genAIRequestStreamKey.Bool(stream),
+	}
+	params := info.sentParameters(input, stream, options, opts)
+	if params.Temperature != nil {
+		attrs = append(attrs, semconv.GenAIRequestTemperature(*params.Temperature))
+	}
+	if params.MaxTokens != nil {
+		attrs = append(attrs, semconv.GenAIRequestMaxTokens(int(*params.MaxTokens)))
+	}
+	if params.TopP != nil {
+		attrs = append(attrs, semconv.GenAIRequestTopP(*params.TopP))
+	}
+	if t.captureContent {
+		attrs = append(attrs, genAIInputMessagesKey.String(marshalAttribute(input.Messages)))
+	}
+
+	return t.tracer.Start(ctx, "chat "+model, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endCompletion 记录响应属性和错误状态，出错时仍记录部分输出
+// endCompletion records the response attributes and error status, including the partial output on errors
+func (t *tracer) endCompletion(span trace.Span, output *Output, err error) {
+	if output != nil {
+		span.SetAttributes(
+			semconv.GenAIUsageInputTokens(int(output.TokenUsage.InputTokens)),
+			semconv.GenAIUsageOutputTokens(int(output.TokenUsage.OutputTokens)),
+		)
+		if output.TokenUsage.CachedTokens > 0 {
+			span.SetAttributes(genAIUsageCachedTokensKey.Int64(output.TokenUsage.CachedTokens))
+		}
+		if output.FinishReason != "" {
+			span.SetAttributes(semconv.GenAIResponseFinishReasons(output.FinishReason))
+		}
+		if t.captureContent {
+			message := AssistantMessageWithTools(output.Content, output.ToolCalls)
+			span.SetAttributes(genAIOutputMessagesKey.String(marshalAttribute([]Message{message})))
+		}
+	}
+	if err != nil {
+		var incomplete *IncompleteError
+		span.SetAttributes(genAIResponseIncompleteKey.Bool(errors.As(err, &incomplete)))
+		recordError(span, err)
+	}
+}
+
+// genAISystem 将提供商类型转换为语义约定中的 gen_ai.system 值
+// genAISystem converts the provider type to the gen_ai.system value of the semantic conventions
+func genAISystem(provider ProviderType) attribute.KeyValue {
+	switch provider {
+	case ProviderOpenAI, ProviderOpenAIResponses:
+		return semconv.GenAISystemOpenAI
+	case ProviderAzure:
+		return semconv.GenAISystemAzureAIOpenAI
+	case ProviderClaude:
+		return semconv.GenAISystemAnthropic
+	case ProviderGemini:
+		return semconv.GenAISystemGCPGemini
+	case "":
+		return semconv.GenAISystemKey.String(string(ProviderCustom))
+	default:
+		return semconv.GenAISystemKey.String(string(provider))
+	}
+}
+
+// requestModel 返回调用使用的模型名称和实际生效的选项
+// 选项按 newOptions 的规则合并客户端选项和本次调用的选项，模型名称依次取 Input、选项和提供商信息
+// requestModel returns the model name of the call together with its effective options
+// The options merge the client options and the per-call options the same way newOptions does; the model is taken
+// from the input, the options and the provider information in that order
+func requestModel(info ProviderInfo, input *Input, opts []Option) (string, *Options) {
+	options := newOptions(info.options, opts...)
+	return cmp.Or(input.Model, options.Model, info.Model), options
+}
+
+// requestParameters 模型请求实际发送的生成参数，未发送的参数为 nil
+// requestParameters are the generation parameters actually sent with a model request; parameters not sent are nil
+type requestParameters struct {
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   *int64
+}
+
+// sentParameters 返回调用实际发送的生成参数；客户端未提供时使用实际生效选项中的非零值
+// sentParameters returns the generation parameters actually sent with the call; when the client does not report
+// them, the non-zero values of the effective options are used
+func (info ProviderInfo) sentParameters(input *Input, stream bool, options *Options, opts []Option) requestParameters {
+	if info.parameters != nil {
+		return info.parameters(input, stream, opts)
+	}
+	var params requestParameters
+	if options.Temperature != 0 {
+		params.Temperature = &options.Temperature
+	}
+	if options.TopP != 0 {
+		params.TopP = &options.TopP
+	}
+	if options.MaxTokens != 0 {
+		params.MaxTokens = &options.MaxTokens
+	}
+	return params
+}
+
+// sdkParameter 将 SDK 的可选参数转换为指针，未设置时返回 nil
+// sdkParameter converts an optional SDK parameter to a pointer, returning nil when it is not set
+func sdkParameter[T any](o interface {
+	Valid() bool
+	Or(T) T
+}) *T {
+	if !o.Valid() {
+		return nil
+	}
+	v := o.Or(*new(T))
+	return &v
+}
+
+// errorType 返回错误的分类，优先使用 LLMError.Code，无法分类时返回 "_OTHER"
+// errorType returns the class of the error, preferring LLMError.Code and falling back to "_OTHER"
+func errorType(err error) string {
+	var llmErr *LLMError
+	switch {
+	case errors.As(err, &llmErr):
+		return llmErr.Code
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	default:
+		return semconv.ErrorTypeOther.Value.AsString()
+	}
+}
+
+// recordError 将错误记录到 span，error.type 优先使用 LLMError.Code
+// recordError records the error on the span, using LLMError.Code as error.type when available
+func recordError(span trace.Span, err error) {
+	span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// marshalAttribute 将值编码为 JSON 字符串属性，编码失败时返回空字符串
+// marshalAttribute encodes the value as a JSON string attribute, returning an empty string on failure
+func marshalAttribute(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ============================================================================
+// 工具执行追踪 / Tool Execution Tracing
+// ============================================================================
+
+// EnableTracing 为工具执行创建 span，span 是调用 Execute 时上下文中 span 的子 span；
+// Runner.Run 会创建 invoke_agent 父 span，直接调用 Execute 时需要调用方在上下文中提供父 span
+// EnableTracing creates a span for every tool execution, as a child of the span in the context passed to Execute;
+// Runner.Run starts an invoke_agent parent span, callers of Execute must provide the parent span in the context
+func (r *ToolRegistry) EnableTracing(opts ...TracingOption) *ToolRegistry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tracer = newTracer(opts...)
+	return r
+}
+
+// startRun 为 Runner.Run 创建 invoke_agent span，未启用追踪时返回原上下文和 nil
+// startRun starts the invoke_agent span of Runner.Run, returning the original context and nil when tracing is disabled
+func (t *tracer) startRun(ctx context.Context, info ProviderInfo, input *Input, opts []Option) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	model, _ := requestModel(info, input, opts)
+	return t.tracer.Start(ctx, "invoke_agent", trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(
+		semconv.GenAIOperationNameInvokeAgent,
+		genAISystem(info.Type),
+		semconv.GenAIRequestModel(model),
+	))
+}
+
+// endRun 记录所有步骤的 token 使用量和错误并结束 span
+// endRun records the token usage of all steps and the error, then ends the span
+func (t *tracer) endRun(span trace.Span, result *RunResult, err error) {
+	if span == nil {
+		return
+	}
+	defer span.End()
+	if result != nil {
+		span.SetAttributes(
+			semconv.GenAIUsageInputTokens(int(result.TokenUsage.InputTokens)),
+			semconv.GenAIUsageOutputTokens(int(result.TokenUsage.OutputTokens)),
+		)
+	}
+	if err != nil {
+		recordError(span, err)
+	}
+}
+
+// startTool 创建工具执行 span，未启用追踪时返回原上下文和 nil
+// startTool starts a tool execution span, returning the original context and nil when tracing is disabled
+func (t *tracer) startTool(ctx context.Context, call ToolCall) (context.Context, trace.Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	attrs := []attribute.KeyValue{
+		semconv.GenAIOperationNameExecuteTool,
+		semconv.GenAIToolName(call.Name),
+		semconv.GenAIToolCallID(call.ID),
+		semconv.GenAIToolType("function"),
+	}
+	if t.captureContent {
+		attrs = append(attrs, genAIToolCallArgumentsKey.String(marshalAttribute(call.Arguments)))
+	}
+	return t.tracer.Start(ctx, "execute_tool "+call.Name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+// endTool 记录工具执行结果并结束 span
+// endTool records the tool result and ends the span
+func (t *tracer) endTool(span trace.Span, content string, err error) {
+	if span == nil {
+		return
+	}
+	defer span.End()
+	if err != nil {
+		recordError(span, err)
+		return
+	}
+	if t.captureContent {
+		span.SetAttributes(genAIToolCallResultKey.String(content))
+	}
+}
@@ -0,0 +1,262 @@
+package OpenLLM
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestTracerProvider 创建将 span 同步写入内存的追踪器提供者
+// newTestTracerProvider creates a tracer provider writing spans synchronously to memory
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+// spanAttributes 将 span 属性转换为 map，便于断言
+// spanAttributes converts the span attributes to a map for assertions
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestTracing_Completion(t *testing.T) {
+	provider, exporter := newTestTracerProvider()
+	llm := &mockLLM{provider: ProviderClaude, responses: []mockResponse{{output: &Output{
+		Content:      "你好",
+		FinishReason: string(FinishReasonStop),
+		TokenUsage:   TokenUsage{InputTokens: 12, OutputTokens: 3, TotalTokens: 15},
+	}}}}
+
+	traced := Chain(llm, Tracing(TracerProvider(provider)))
+	if _, err := traced.Completion(context.Background(), &Input{Model: "claude-test", Messages: []Message{UserMessage("你好")}},
+		Temperature(0.5), MaxTokens(256)); err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "chat claude-test" {
+		t.Errorf("Expected span name 'chat claude-test', got %s", span.Name)
+	}
+	attrs := spanAttributes(span)
+	if attrs["gen_ai.system"].AsString() != "anthropic" {
+		t.Errorf("Expected gen_ai.system anthropic, got %v", attrs["gen_ai.system"])
+	}
+	if attrs["gen_ai.request.model"].AsString() != "claude-test" {
+		t.Errorf("Expected request model, got %v", attrs["gen_ai.request.model"])
+	}
+	if attrs["gen_ai.request.temperature"].AsFloat64() != 0.5 || attrs["gen_ai.request.max_tokens"].AsInt64() != 256 {
+		t.Errorf("Expected request parameters, got %v", attrs)
+	}
+	if attrs["gen_ai.usage.input_tokens"].AsInt64() != 12 || attrs["gen_ai.usage.output_tokens"].AsInt64() != 3 {
+		t.Errorf("Expected token usage, got %v", attrs)
+	}
+	if reasons := attrs["gen_ai.response.finish_reasons"].AsStringSlice(); len(reasons) != 1 || reasons[0] != string(FinishReasonStop) {
+		t.Errorf("Expected finish reason, got %v", reasons)
+	}
+	if _, ok := attrs["gen_ai.input.messages"]; ok {
+		t.Error("Expected prompts not to be captured by default")
+	}
+	if span.Status.Code != codes.Unset {
+		t.Errorf("Expected unset status, got %v", span.Status)
+	}
+}
+
+func TestTracing_ClientOptions(t *testing.T) {
+	server := newErrorServer(t, http.StatusOK, nil, `{
+		"id": "1", "object": "chat.completion", "created": 1, "model": "gpt-4o",
+		"choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "你好"}}],
+		"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+	}`)
+	provider, exporter := newTestTracerProvider()
+	collector := &metricsCollector{}
+	client := CreateOpenAI(URL(server.URL), APIKey("test"), Model("gpt-4o"), Temperature(0.7), TopP(0.9))
+	traced := Chain(client, Tracing(TracerProvider(provider)), Metrics(collector))
+
+	if _, err := traced.Completion(context.Background(), &Input{Messages: []Message{UserMessage("你好")}}, MaxTokens(512)); err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	attrs := spanAttributes(spans[0])
+	if attrs["gen_ai.request.model"].AsString() != "gpt-4o" || attrs["gen_ai.request.temperature"].AsFloat64() != 0.7 ||
+		attrs["gen_ai.request.top_p"].AsFloat64() != 0.9 || attrs["gen_ai.request.max_tokens"].AsInt64() != 512 {
+		t.Errorf("Expected client options merged with per-call options, got %v", attrs)
+	}
+	if len(collector.metrics) != 1 || collector.metrics[0].Model != "gpt-4o" {
+		t.Errorf("Expected metrics to use the client model, got %+v", collector.metrics)
+	}
+
+	// 未设置时使用 newOptions 的默认值 / Defaults from newOptions apply when nothing is set
+	exporter.Reset()
+	if _, err := Chain(CreateOpenAI(URL(server.URL), APIKey("test")), Tracing(TracerProvider(provider))).Completion(
+		context.Background(), &Input{Model: "gpt-4o", Messages: []Message{UserMessage("你好")}}); err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	defaults := newOptions(nil)
+	attrs = spanAttributes(exporter.GetSpans()[0])
+	if attrs["gen_ai.request.temperature"].AsFloat64() != defaults.Temperature || attrs["gen_ai.request.max_tokens"].AsInt64() != defaults.MaxTokens {
+		t.Errorf("Expected default request parameters, got %v", attrs)
+	}
+}
+
+func TestTracing_SentParameters(t *testing.T) {
+	provider, exporter := newTestTracerProvider()
+	tracer := newTracer(TracerProvider(provider))
+	tests := []struct {
+		name   string
+		info   ProviderInfo
+		model  string
+		stream bool
+		opts   []Option
+		want   map[string]any // nil 表示未发送 / nil means not sent
+	}{
+		{"Azure不发送采样参数", CreateAzure(APIKey("test")).Provider(), "gpt-4o", false, nil,
+			map[string]any{"temperature": nil, "top_p": nil, "max_tokens": int64(defaultMaxTokens)}},
+		{"Gemini未设置时不发送max_tokens", (&Gemini{}).Provider(), "", false, nil,
+			map[string]any{"temperature": 0.2, "top_p": nil, "max_tokens": nil}},
+		{"Gemini显式设置", (&Gemini{}).Provider(), "", false, []Option{MaxTokens(defaultMaxTokens), TopP(0.9)},
+			map[string]any{"temperature": 0.2, "top_p": 0.9, "max_tokens": int64(65536)}},
+		{"Claude只发送temperature", CreateAnthropic(APIKey("test")).Provider(), "claude-sonnet-4-5", true, nil,
+			map[string]any{"temperature": 0.2, "top_p": nil, "max_tokens": int64(64000)}},
+		{"Claude只发送top_p", CreateAnthropic(APIKey("test"), TopP(0.9)).Provider(), "claude-sonnet-4-5", false, nil,
+			map[string]any{"temperature": nil, "top_p": 0.9, "max_tokens": int64(anthropicNonStreamingMaxTokens)}},
+		{"Responses推理模型", CreateOpenAIResponses(APIKey("test")).Provider(), "o3", false, nil,
+			map[string]any{"temperature": nil, "top_p": nil, "max_tokens": int64(100000)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter.Reset()
+			_, span := tracer.startCompletion(context.Background(), tt.info, &Input{Model: tt.model}, tt.stream, tt.opts)
+			span.End()
+			attrs := spanAttributes(exporter.GetSpans()[0])
+			for name, want := range tt.want {
+				got, ok := attrs[attribute.Key("gen_ai.request."+name)]
+				switch {
+				case want == nil && ok:
+					t.Errorf("Expected %s not to be recorded, got %v", name, got.Emit())
+				case want != nil && (!ok || got.AsInterface() != want):
+					t.Errorf("Expected %s=%v, got %v", name, want, got.Emit())
+				}
+			}
+		})
+	}
+}
+
+func TestTracing_StreamError(t *testing.T) {
+	provider, exporter := newTestTracerProvider()
+	llmErr := NewLLMError(ProviderOpenAI, ErrCodeRateLimited, "请求频率超限", nil)
+	llm := &mockLLM{provider: ProviderOpenAI, responses: []mockResponse{{
+		output: &Output{Content: "部分", FinishReason: string(FinishReasonError)},
+		err:    &IncompleteError{Err: llmErr},
+	}}}
+
+	traced := Chain(llm, Tracing(TracerProvider(provider), CaptureContent()))
+	_, err := traced.CompletionStream(context.Background(), &Input{Model: "gpt-test", Messages: []Message{UserMessage("秘密问题")}},
+		func(string) {})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	span := exporter.GetSpans()[0]
+	attrs := spanAttributes(span)
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status)
+	}
+	if attrs["error.type"].AsString() != ErrCodeRateLimited {
+		t.Errorf("Expected error.type %s, got %v", ErrCodeRateLimited, attrs["error.type"])
+	}
+	if !attrs["gen_ai.response.incomplete"].AsBool() {
+		t.Error("Expected the span to be marked incomplete")
+	}
+	if _, ok := attrs["gen_ai.response.time_to_first_token"]; !ok {
+		t.Error("Expected time to first token to be recorded")
+	}
+	if !strings.Contains(attrs["gen_ai.input.messages"].AsString(), "秘密问题") {
+		t.Errorf("Expected captured prompt, got %v", attrs["gen_ai.input.messages"])
+	}
+	if !strings.Contains(attrs["gen_ai.output.messages"].AsString(), "部分") {
+		t.Errorf("Expected captured partial completion, got %v", attrs["gen_ai.output.messages"])
+	}
+}
+
+func TestToolRegistry_EnableTracing(t *testing.T) {
+	provider, exporter := newTestTracerProvider()
+	registry := newWeatherRegistry(t).EnableTracing(TracerProvider(provider), CaptureContent())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "agent")
+	registry.ExecuteAll(ctx, []ToolCall{
+		{ID: "call_1", Name: "query_weather", Arguments: map[string]any{"city": "北京"}},
+		{ID: "call_2", Name: "query_weather", Arguments: map[string]any{"city": "火星"}},
+	})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	for i, span := range spans[:2] {
+		if span.Name != "execute_tool query_weather" {
+			t.Errorf("Expected tool span name, got %s", span.Name)
+		}
+		if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Expected tool span %d to be a child of the caller's span", i)
+		}
+	}
+
+	ok, failed := spanAttributes(spans[0]), spanAttributes(spans[1])
+	if ok["gen_ai.tool.call.id"].AsString() != "call_1" || !strings.Contains(ok["gen_ai.tool.call.result"].AsString(), "晴") {
+		t.Errorf("Expected tool call attributes, got %v", ok)
+	}
+	if spans[1].Status.Code != codes.Error || failed["error.type"].AsString() != "_OTHER" {
+		t.Errorf("Expected failed tool span, got %v %v", spans[1].Status, failed["error.type"])
+	}
+}
+
+func TestRunner_Tracing(t *testing.T) {
+	provider, exporter := newTestTracerProvider()
+	llm := &mockLLM{provider: ProviderOpenAI, responses: []mockResponse{
+		{output: weatherToolCallOutput("call_1", "北京")},
+		{output: &Output{Content: "北京晴", FinishReason: string(FinishReasonStop), TokenUsage: TokenUsage{InputTokens: 20, OutputTokens: 5}}},
+	}}
+	registry := newWeatherRegistry(t).EnableTracing(TracerProvider(provider))
+	runner := NewRunner(Chain(llm, Tracing(TracerProvider(provider))), registry)
+
+	if _, err := runner.Run(context.Background(), &Input{Model: "gpt-4o", Messages: []Message{UserMessage("北京天气")}}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// chat, execute_tool, chat, invoke_agent
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	root := spans[3]
+	if root.Name != "invoke_agent" || root.Parent.IsValid() {
+		t.Fatalf("Expected the invoke_agent root span last, got %s", root.Name)
+	}
+	for _, span := range spans[:3] {
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("Expected span %s to be a child of invoke_agent", span.Name)
+		}
+	}
+	if attrs := spanAttributes(root); attrs["gen_ai.usage.input_tokens"].AsInt64() != 30 || attrs["gen_ai.request.model"].AsString() != "gpt-4o" {
+		t.Errorf("Unexpected invoke_agent attributes: %v", attrs)
+	}
+}